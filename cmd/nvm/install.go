@@ -0,0 +1,432 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aronhoyer/go-nvm/internal/cli"
+	"github.com/aronhoyer/go-nvm/internal/node"
+	"github.com/aronhoyer/go-nvm/internal/platform"
+)
+
+// installLockTimeout is how long to wait for another process installing the same version to finish.
+const installLockTimeout = 10 * time.Minute
+
+// maxConcurrentInstalls bounds the number of versions downloaded and extracted at the same time.
+const maxConcurrentInstalls = 4
+
+type installOptions struct {
+	// use activates the version once it's installed
+	use                 bool
+	skipDefaultPackages bool
+	corepack            bool
+	// npmRange is the npm version to install in place of the bundled one
+	npmRange string
+	// packagesFrom is an installed version whose global packages are reinstalled into the new one
+	packagesFrom string
+	// arch is the architecture of the release to download, empty for the host's
+	arch string
+	// verify checks the downloaded release against its published checksum
+	verify bool
+}
+
+// defaultInstallOptions returns the install options set in the config.
+func defaultInstallOptions() installOptions {
+	return installOptions{
+		corepack: cfg.Corepack,
+		arch:     cfg.Arch,
+		verify:   cfg.Verify,
+	}
+}
+
+// errAlreadyInstalled is returned by fetchVersion if the version was installed by another process in the meantime.
+var errAlreadyInstalled = errors.New("version already installed")
+
+// installVersion downloads and extracts version, a version in the remote index, and runs the post-install steps
+// in opts. Only the post-install steps hold the state lock, the download is guarded by the version's own lock.
+func installVersion(c *cli.Cli, version string, opts installOptions) error {
+	if err := fetchVersion(c, version, opts); err != nil {
+		return err
+	}
+
+	unlock, err := lockState(c)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return setupVersion(c, version, opts)
+}
+
+// installMissingVersion installs the newest release that spec matches, for when no installed version does, and
+// returns it. An empty spec is read from the nearest version file. The caller mustn't hold the state lock, which
+// is only taken while the LTS index is written and the version is set up.
+func installMissingVersion(c *cli.Cli, spec string) (string, error) {
+	spec, err := versionSpec(c.Paths(), spec)
+	if err != nil {
+		return "", err
+	}
+
+	// an alias may point to a version that's not installed, which is what has to be installed then
+	target, err := node.ResolveAlias(c.Paths(), spec)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", cli.ExitCodeUsage, err)
+	}
+
+	idx, err := node.GetRemoteIndex()
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to retrieve node distribution index: %s", cli.ExitCodeUnavailable, err)
+	}
+
+	if err := updateLTSIndex(c, idx); err != nil {
+		return "", err
+	}
+
+	entry, err := resolveRemoteVersion(idx, target)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(os.Stderr, "No installed version matches %s, installing %s...\n", spec, entry.Version)
+
+	if err := installVersion(c, entry.Version, defaultInstallOptions()); err != nil && !errors.Is(err, errAlreadyInstalled) {
+		return "", err
+	}
+
+	return entry.Version, nil
+}
+
+// resolveOrInstallVersion is like resolveInstalledVersion, but installs a missing version if install is set. See
+// [installMissingVersion] for the locking.
+func resolveOrInstallVersion(c *cli.Cli, spec string, install bool) (string, error) {
+	version, err := resolveInstalledVersion(c.Paths(), spec)
+	if !install || !isMissingVersion(err) {
+		return version, err
+	}
+
+	return installMissingVersion(c, spec)
+}
+
+// installVersions installs several versions at once. Downloads and extraction run concurrently, the post-install
+// steps in opts, which print their progress, run for one version at a time while holding the state lock. If
+// opts.use is set, the first version is activated.
+func installVersions(c *cli.Cli, versions []string, opts installOptions) error {
+	if len(versions) == 1 {
+		err := installVersion(c, versions[0], opts)
+		if errors.Is(err, errAlreadyInstalled) {
+			return fmt.Errorf("%w: version already installed: %s", cli.ExitCodeUsage, versions[0])
+		}
+
+		return err
+	}
+
+	fmt.Printf("Installing %s...\n", strings.Join(versions, ", "))
+
+	results := make([]error, len(versions))
+	sem := make(chan struct{}, maxConcurrentInstalls)
+	var wg sync.WaitGroup
+
+	for i, version := range versions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = fetchVersion(c, version, opts)
+		}()
+	}
+
+	wg.Wait()
+
+	unlock, err := lockState(c)
+	if err != nil {
+		return err
+	}
+
+	for i, version := range versions {
+		if results[i] == nil {
+			o := opts
+			o.use = opts.use && i == 0
+			results[i] = setupVersion(c, version, o)
+		}
+	}
+
+	unlock()
+
+	failed := 0
+	fmt.Println("\nSummary:")
+	for i, version := range versions {
+		switch err := results[i]; {
+		case err == nil:
+			fmt.Printf("  %-12s installed\n", version)
+		case errors.Is(err, errAlreadyInstalled):
+			fmt.Printf("  %-12s already installed\n", version)
+		default:
+			failed++
+			fmt.Printf("  %-12s failed: %s\n", version, err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d versions failed to install", cli.ExitCodeSoftware, failed, len(versions))
+	}
+
+	return nil
+}
+
+// fetchVersion downloads and extracts version into the versions directory. It holds a lock on the version while
+// doing so, and extracts into a staging directory that is only moved into place once it's complete, so concurrent
+// installs of the same version can't collide and a failed install leaves nothing behind.
+func fetchVersion(c *cli.Cli, version string, opts installOptions) error {
+	lock, err := platform.AcquireLock(path.Join(c.Paths().Versions(), "."+version+".lock"), installLockTimeout)
+	if err != nil {
+		var locked *platform.LockedError
+		if errors.As(err, &locked) {
+			return fmt.Errorf("%w: %s is being installed by another process (pid %d)", cli.ExitCodeTempFail, version, locked.PID)
+		}
+
+		return fmt.Errorf("%w: unable to lock %s: %s", cli.ExitCodeIOErr, version, err)
+	}
+	defer lock.Unlock()
+
+	extractionDst := c.Paths().Version(version)
+	if _, err := os.Stat(extractionDst); err == nil {
+		return errAlreadyInstalled
+	}
+
+	hostOS, hostArch := platform.SysInfoNorm()
+	if opts.arch != "" {
+		hostArch = opts.arch
+	}
+
+	var artifactExtension string
+	switch hostOS {
+	case "win":
+		artifactExtension = ".zip"
+	default:
+		if platform.HasXZSupport(hostOS) {
+			artifactExtension = ".tar.xz"
+		} else {
+			artifactExtension = ".tar.gz"
+		}
+	}
+
+	slug := node.ArtifactSlug(version, hostOS, hostArch, artifactExtension)
+	slog.Info("installing", "version", version, "artifact", slug, "dst", extractionDst)
+
+	artifact, err := node.DownloadArtifact(version, slug)
+	if err != nil {
+		return fmt.Errorf("%w: failed to download artifact %s: %s", cli.ExitCodeSoftware, slug, err)
+	}
+	defer os.Remove(artifact.Name)
+
+	if opts.verify {
+		if err := node.VerifyArtifact(version, artifact); err != nil {
+			return fmt.Errorf("%w: failed to verify artifact %s: %s", cli.ExitCodeSoftware, slug, err)
+		}
+	}
+
+	// staging directories start with a dot, which keeps them out of the local index
+	staging, err := os.MkdirTemp(c.Paths().Versions(), "."+version+"-")
+	if err != nil {
+		return fmt.Errorf("%w: failed to create extraction destination %s: %s", cli.ExitCodeCantCreate, extractionDst, err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := node.ExtractArtifact(artifact.Name, staging); err != nil {
+		return fmt.Errorf("%w: failed to extract artifact %s: %s", cli.ExitCodeSoftware, artifact.Name, err)
+	}
+
+	// MkdirTemp creates directories only the owner can access
+	if err := os.Chmod(staging, 0o755); err != nil {
+		return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
+	}
+
+	if err := node.WriteMetadata(staging, node.Metadata{InstalledAt: time.Now().UTC()}); err != nil {
+		return fmt.Errorf("%w: unable to write metadata of %s: %s", cli.ExitCodeIOErr, version, err)
+	}
+
+	if err := os.Rename(staging, extractionDst); err != nil {
+		return fmt.Errorf("%w: failed to move %s into place: %s", cli.ExitCodeIOErr, version, err)
+	}
+
+	return nil
+}
+
+// setupVersion runs the post-install steps in opts for the installed version.
+func setupVersion(c *cli.Cli, version string, opts installOptions) error {
+	extractionDst := c.Paths().Version(version)
+
+	if opts.npmRange != "" {
+		fmt.Printf("Installing npm@%s...\n", opts.npmRange)
+
+		npmVersion, err := node.InstallNpm(node.ExecRunner{}, extractionDst, opts.npmRange)
+		if err != nil {
+			return fmt.Errorf("%w: failed to install npm@%s: %s", cli.ExitCodeSoftware, opts.npmRange, err)
+		}
+
+		fmt.Printf("Installed npm %s\n", npmVersion)
+	}
+
+	if opts.packagesFrom != "" {
+		if err := reinstallPackages(c.Paths().Versions(), opts.packagesFrom, version); err != nil {
+			return err
+		}
+	}
+
+	if !opts.skipDefaultPackages {
+		defaultPackages := c.Paths().DefaultPackages()
+
+		pkgs, err := node.ReadDefaultPackages(defaultPackages)
+		if err != nil {
+			return fmt.Errorf("%w: unable to read %s: %s", cli.ExitCodeIOErr, defaultPackages, err)
+		}
+
+		if len(pkgs) > 0 {
+			fmt.Println("Installing default packages...")
+			installPackages(extractionDst, pkgs)
+		}
+	}
+
+	if opts.corepack {
+		if err := node.EnableCorepack(node.ExecRunner{}, extractionDst); err != nil {
+			// the installation itself is fine, so don't fail it over the shims
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+		}
+	}
+
+	if opts.use {
+		return activateVersion(c, version)
+	}
+
+	return nil
+}
+
+// activateVersion points the bin link at version. For the system version, the link is removed instead, so PATH
+// lookups fall through to the system Node.
+func activateVersion(c *cli.Cli, version string) error {
+	if version == node.SystemVersion {
+		if err := os.RemoveAll(c.Paths().Bin()); err != nil {
+			return fmt.Errorf("%w: failed to remove existing bin: %s", cli.ExitCodeIOErr, err)
+		}
+
+		return nil
+	}
+
+	// swap the link in place, so shells never see a missing bin between two versions
+	vbin := path.Join(c.Paths().Version(version), "bin")
+	if err := platform.SymlinkForce(vbin, c.Paths().Bin()); err != nil {
+		return fmt.Errorf("%w: failed to symlink version %s: %s", cli.ExitCodeIOErr, version, err)
+	}
+
+	return nil
+}
+
+// updateLTSIndex writes the LTS index of idx, the remote index, holding the state lock. See [writeLTSIndex].
+func updateLTSIndex(c *cli.Cli, idx []node.IndexEntry) error {
+	unlock, err := lockState(c)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return writeLTSIndex(c.Paths().LTS(), idx)
+}
+
+// writeLTSIndex stores the newest version of each LTS line in idx, the remote index, in ltsDir, and links the
+// newest line as "latest".
+func writeLTSIndex(ltsDir string, idx []node.IndexEntry) error {
+	// this gets written on every install, cause i can't be assed to cache bust
+	if err := os.MkdirAll(ltsDir, 0o755); err != nil {
+		return fmt.Errorf("%w: unable to create lts directory: %s", cli.ExitCodeCantCreate, err)
+	}
+
+	latestLTS := ""
+	writtenLTS := make(map[string]bool)
+
+	for _, e := range idx {
+		ltsName := strings.ToLower(e.LTS)
+		if _, ok := writtenLTS[ltsName]; e.LTS != "" && !ok {
+			p := path.Join(ltsDir, ltsName)
+			if err := os.WriteFile(p, []byte(e.Version), 0o644); err != nil {
+				return fmt.Errorf("%w: unable to write lts file: %s", cli.ExitCodeIOErr, err)
+			}
+
+			if latestLTS == "" {
+				latestLTS = ltsName
+			}
+
+			writtenLTS[ltsName] = true
+		}
+	}
+
+	if latestLTS == "" {
+		return nil
+	}
+
+	// the link is relative, so it keeps working when NVMDIR is moved
+	if err := platform.SymlinkForce(latestLTS, path.Join(ltsDir, "latest")); err != nil {
+		return fmt.Errorf("%w: unable to symlink latest lts: %s", cli.ExitCodeIOErr, err)
+	}
+
+	return nil
+}
+
+// resolveRemoteVersion resolves spec against idx, the remote index. See [node.ResolveRemote].
+func resolveRemoteVersion(idx []node.IndexEntry, spec string) (node.IndexEntry, error) {
+	entry, err := node.ResolveRemote(idx, spec)
+	if err != nil {
+		if errors.Is(err, node.ErrUnknownVersion) {
+			return entry, fmt.Errorf("%w: no such version: %s", cli.ExitCodeUsage, spec)
+		}
+
+		return entry, fmt.Errorf("%w: %s", cli.ExitCodeSoftware, err)
+	}
+
+	return entry, nil
+}
+
+// reinstallPackages installs the global packages of the installed version from into the installed version to.
+func reinstallPackages(versionsDir, from, to string) error {
+	pkgs, linked, err := node.GlobalPackages(path.Join(versionsDir, from))
+	if err != nil {
+		return fmt.Errorf("%w: unable to read global packages of %s: %s", cli.ExitCodeIOErr, from, err)
+	}
+
+	for _, name := range linked {
+		fmt.Fprintf(os.Stderr, "Skipping %s: linked packages must be relinked manually\n", name)
+	}
+
+	if len(pkgs) > 0 {
+		fmt.Printf("Reinstalling global packages from %s...\n", from)
+		installPackages(path.Join(versionsDir, to), pkgs)
+	}
+
+	return nil
+}
+
+// installPackages installs global packages into the Node installation at versionDir, reporting packages that fail
+// to install without giving up on the rest.
+func installPackages(versionDir string, pkgs []string) {
+	failed := 0
+	for _, r := range node.InstallGlobalPackages(node.ExecRunner{}, versionDir, pkgs) {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "  %s: failed: %s\n", r.Name, r.Err)
+		} else {
+			fmt.Printf("  %s\n", r.Name)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d packages failed to install\n", failed, len(pkgs))
+	}
+}
@@ -1,49 +1,82 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
 	"os"
+	"os/exec"
 	"path"
-	"regexp"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aronhoyer/go-nvm/internal/cli"
+	"github.com/aronhoyer/go-nvm/internal/config"
 	"github.com/aronhoyer/go-nvm/internal/node"
+	"github.com/aronhoyer/go-nvm/internal/paths"
 	"github.com/aronhoyer/go-nvm/internal/platform"
+	"github.com/aronhoyer/go-nvm/internal/semver"
 )
 
 var (
-	nvmDirPath string
-	commitSha  string
-	version    = "dev"
+	nvmPaths paths.Paths
+	cfg      config.Config
+	// cfgErr is why cfg couldn't be loaded completely, see requireConfig
+	cfgErr    error
+	commitSha string
+	version   = "dev"
 )
 
 func init() {
-	if nvmDirPath = os.Getenv("NVMDIR"); nvmDirPath == "" {
-		if home, err := os.UserHomeDir(); err != nil {
-			fmt.Fprintln(os.Stderr, "Error: failed to determine home directory")
-			fmt.Println("Try setting the NVMDIR environment variable in your shell's profile")
-		} else {
-			os.Setenv("NVMDIR", path.Join(home, ".nvm"))
+	var err error
+	if nvmPaths, err = paths.Resolve(); err != nil {
+		cli.PrintError(err)
+		fmt.Println("Try setting the NVMDIR environment variable in your shell's profile")
+		os.Exit(cli.ExitCodeOSErr.Code())
+	}
+
+	if nvmPaths.Legacy != "" {
+		if err := migrateLegacyDir(nvmPaths); err != nil {
+			cli.PrintError(err)
+			os.Exit(cli.ExitCodeIOErr.Code())
 		}
 	}
 
-	if err := os.MkdirAll(path.Join(nvmDirPath, "versions"), 0o755); err != nil {
-		fmt.Fprintln(os.Stderr, "\x1b[1;31mError:\x1b[0m", err)
+	if err := nvmPaths.Init(); err != nil {
+		cli.PrintError(err)
 		os.Exit(cli.ExitCodeIOErr.Code())
 	}
 
+	// invalid settings fall back to their defaults, and only fail the commands that depend on them
+	cfg, cfgErr = config.Load(nvmPaths.ConfigFile)
+
+	cli.Color = cfg.Color
+	if err := cli.SetColor(cli.Color); err != nil {
+		cli.PrintError(err)
+		os.Exit(cli.ExitCodeConfig.Code())
+	}
+
+	node.DistURL = cfg.Mirror
+	node.IndexTTL = cfg.CacheTTL
+	node.VersionFiles = cfg.VersionFiles
+	node.CacheDir = nvmPaths.Cache
+	if u := os.Getenv("NVM_SCHEDULE_URL"); u != "" {
+		node.ScheduleURL = u
+	}
+
 	cli.Version = func() {
 		fmt.Printf("%s (%s)\n", version, commitSha)
 	}
 }
 
 func main() {
-	c := cli.New(nvmDirPath, &cli.Command{
+	c := cli.New(nvmPaths, &cli.Command{
 		Name:        "nvm",
 		Description: "Manage Node.js versions",
 	})
@@ -52,137 +85,198 @@ func main() {
 		Name:        "install",
 		Aliases:     []string{"i"},
 		Description: "Install a Node version",
-		Usage:       "nvm {i,install} [VERSION] [OPTIONS]",
+		Usage:       "nvm {i,install} [VERSION...] [OPTIONS]",
 		Flags: []cli.Flag{
 			cli.NewBoolFlagP("use", "u", false, "Activate installed version after install"),
+			cli.NewStringFlagP("reinstall-packages-from", "", "", "Install the global npm packages of another installed version"),
+			cli.NewBoolFlagP("skip-default-packages", "", false, "Don't install the packages listed in $NVMDIR/default-packages"),
+			cli.NewBoolFlagP("corepack", "", cfg.Corepack, "Enable corepack package manager shims after install"),
+			cli.NewStringFlagP("npm", "", "", "Install an npm version matching this range instead of the bundled one"),
+			cli.NewBoolFlagP("fail-on-eol", "", false, "Fail if the version has reached end-of-life"),
+			cli.NewStringFlagP("mirror", "", cfg.Mirror, "Base URL of the Node distribution to install from"),
+			cli.NewStringFlagP("arch", "", cfg.Arch, "Architecture of the release to install, e.g. arm64"),
+			cli.NewBoolFlagP("verify", "", cfg.Verify, "Verify the release against its published checksum"),
 		},
 		Run: func(args cli.Args, flags cli.FlagSet) error {
+			specs := []string(args)
+			if len(specs) == 0 {
+				specs = []string{"latest"}
+			}
+
+			if len(specs) > 1 && flags.GetBool("use") {
+				return fmt.Errorf("%w: --use takes a single version", cli.ExitCodeUsage)
+			}
+
+			// flags are validated like the settings they override
+			flagCfg := cfg
+			for _, key := range []string{"mirror", "arch"} {
+				if err := flagCfg.Set(key, flags.GetString(key)); err != nil {
+					return fmt.Errorf("%w: --%s", cli.ExitCodeUsage, err)
+				}
+			}
+			node.DistURL = flagCfg.Mirror
+
 			idx, err := node.GetRemoteIndex()
 			if err != nil {
 				return fmt.Errorf("%w: unable to retrieve node distribution index: %s", cli.ExitCodeUnavailable, err)
 			}
 
-			// store index of latest lts versions
-			// this gets written on every install, cause i can't be assed to cache bust
-			ltsDir := path.Join(c.RootPath(), "lts")
-			os.MkdirAll(ltsDir, 0o755)
+			// the state lock is only taken for the steps that need it, so a slow download doesn't hold up other nvm
+			// processes
+			if err := updateLTSIndex(c, idx); err != nil {
+				return err
+			}
+
+			var versions []string
+			for _, spec := range specs {
+				entry, err := resolveRemoteVersion(idx, spec)
+				if err != nil {
+					return err
+				}
 
-			latestLTSPath := ""
-			writtenLTS := make(map[string]bool)
+				if err := checkEOL(entry.Version, flags.GetBool("fail-on-eol")); err != nil {
+					return err
+				}
 
-			for _, e := range idx {
-				ltsName := strings.ToLower(e.LTS)
-				if _, ok := writtenLTS[ltsName]; e.LTS != "" && !ok {
-					p := path.Join(ltsDir, ltsName)
-					if err := os.WriteFile(p, []byte(e.Version), 0o644); err != nil {
-						return fmt.Errorf("%w: unable to write lts file: %s", cli.ExitCodeIOErr, err)
-					}
+				if !slices.Contains(versions, entry.Version) {
+					versions = append(versions, entry.Version)
+				}
+			}
 
-					if latestLTSPath == "" {
-						latestLTSPath = p
-					}
+			idx, err = node.GetLocalIndex(c.Paths().Versions())
+			if err != nil {
+				return fmt.Errorf("%w: unable to read local index", cli.ExitCodeIOErr)
+			}
 
-					writtenLTS[ltsName] = true
+			if len(versions) == 1 {
+				for _, e := range idx {
+					if e.Version == versions[0] {
+						return fmt.Errorf("%w: version already installed: %s", cli.ExitCodeUsage, e.Version)
+					}
 				}
 			}
 
-			if err := platform.SymlinkForce(latestLTSPath, path.Join(ltsDir, "latest")); err != nil {
-				return fmt.Errorf("%w: unable to symlink latest lts: %s", cli.ExitCodeIOErr, err)
+			opts := installOptions{
+				use:                 len(idx) == 0 || flags.GetBool("use"),
+				skipDefaultPackages: flags.GetBool("skip-default-packages"),
+				corepack:            flags.GetBool("corepack"),
+				npmRange:            flags.GetString("npm"),
+				arch:                flagCfg.Arch,
+				verify:              flags.GetBool("verify"),
 			}
 
-			version := strings.ToLower(args.Get(0))
+			if opts.npmRange != "" && opts.npmRange != "latest" {
+				if _, err := semver.ParseRange(opts.npmRange); err != nil {
+					return fmt.Errorf("%w: %s", cli.ExitCodeUsage, err)
+				}
+			}
 
-			switch version {
-			case "", "latest":
-				version = idx[0].Version
-			case "lts":
-				b, err := os.ReadFile(latestLTSPath)
-				if err != nil {
-					return fmt.Errorf("%w: unable to read latest lts: %s", cli.ExitCodeIOErr, err)
+			if from := flags.GetString("reinstall-packages-from"); from != "" {
+				if opts.packagesFrom, err = resolveInstalledVersion(c.Paths(), from); err != nil {
+					return err
 				}
-				version = string(b)
-			default:
-				// try reading lts file if we can't parse version
-				if _, _, _, err := parseVersion(version); err != nil {
-					b, err := os.ReadFile(path.Join(ltsDir, version))
-					if err != nil {
-						return fmt.Errorf("%w: unable to read lts file: %s", cli.ExitCodeIOErr, err)
-					}
-					version = string(b)
-				} else {
-					if !strings.HasPrefix(version, "v") {
-						version = "v" + version
-					}
+
+				if opts.packagesFrom == node.SystemVersion {
+					return fmt.Errorf("%w: cannot reinstall packages from the system Node", cli.ExitCodeUsage)
 				}
 			}
 
-			var entry *node.IndexEntry
+			return installVersions(c, versions, opts)
+		},
+	})
 
-			// linear search because it's more likely that you'd wanna install a newer version rather than an
-			// old version
-			for _, e := range idx {
-				if versionsMatcheEager(version, e.Version) {
-					entry = &e
-					break
-				}
+	c.AddCommand(&cli.Command{
+		Name:        "upgrade",
+		Description: "Install the newest release in the major line of an installed version",
+		Usage:       "nvm upgrade [MAJOR|ALIAS] [OPTIONS]",
+		Flags: []cli.Flag{
+			cli.NewBoolFlagP("reinstall-packages", "p", false, "Reinstall global npm packages from the old version"),
+			cli.NewBoolFlagP("remove-old", "", false, "Remove the old version after upgrading"),
+		},
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			old, err := versionOrActive(c, args.Get(0))
+			if err != nil {
+				return err
 			}
 
-			if entry == nil {
-				return fmt.Errorf("%w: no such version: %s", cli.ExitCodeUsage, version)
+			oldVersion, err := semver.Parse(old)
+			if err != nil {
+				return fmt.Errorf("%w: %s", cli.ExitCodeDataErr, err)
 			}
 
-			idx, err = node.GetLocalIndex(c.VersionsDirPath())
+			idx, err := node.GetRemoteIndex()
 			if err != nil {
-				return fmt.Errorf("%w: unable to read local index", cli.ExitCodeIOErr)
+				return fmt.Errorf("%w: unable to retrieve node distribution index: %s", cli.ExitCodeUnavailable, err)
 			}
 
-			for _, e := range idx {
-				if e.Version == entry.Version {
-					return fmt.Errorf("%w: version already installed: %s", cli.ExitCodeUsage, e.Version)
-				}
+			if err := updateLTSIndex(c, idx); err != nil {
+				return err
 			}
 
-			hostOS, hostArch := platform.SysInfoNorm()
-			var artifactExtension string
-			switch hostOS {
-			case "win":
-				artifactExtension = ".zip"
-			default:
-				if platform.HasXZSupport(hostOS) {
-					artifactExtension = ".tar.xz"
-				} else {
-					artifactExtension = ".tar.gz"
+			entry, err := resolveRemoteVersion(idx, strconv.Itoa(oldVersion.Major))
+			if err != nil {
+				return err
+			}
+
+			if semver.Compare(entry.Version, old) <= 0 {
+				fmt.Printf("Node %s is already the newest v%d release\n", old, oldVersion.Major)
+				return nil
+			}
+
+			if _, err := os.Stat(c.Paths().Version(entry.Version)); err == nil {
+				fmt.Printf("Node %s is already installed\n", entry.Version)
+			} else {
+				opts := defaultInstallOptions()
+				if flags.GetBool("reinstall-packages") {
+					opts.packagesFrom = old
+				}
+
+				if err := installVersion(c, entry.Version, opts); err != nil && !errors.Is(err, errAlreadyInstalled) {
+					return err
 				}
 			}
 
-			slug := node.ArtifactSlug(entry.Version, hostOS, hostArch, artifactExtension)
+			unlock, err := lockState(c)
+			if err != nil {
+				return err
+			}
+			defer unlock()
 
-			artifact, err := node.DownloadArtifact(entry.Version, slug)
+			aliasDir := c.Paths().Aliases()
+			aliases, err := node.ListAliases(aliasDir)
 			if err != nil {
-				return fmt.Errorf("%w: failed to download artifact %s", cli.ExitCodeSoftware, slug)
+				return fmt.Errorf("%w: unable to read aliases: %s", cli.ExitCodeIOErr, err)
 			}
 
-			extractionDst := path.Join(c.VersionsDirPath(), entry.Version)
+			// aliases to a range or LTS line already resolve to the new version, only exact ones need updating
+			for _, a := range aliases {
+				if v, err := semver.Parse(a.Target); err == nil && v.Compare(oldVersion) == 0 {
+					if err := node.WriteAlias(aliasDir, a.Name, entry.Version); err != nil {
+						return fmt.Errorf("%w: unable to update alias %s: %s", cli.ExitCodeIOErr, a.Name, err)
+					}
 
-			if err := os.MkdirAll(extractionDst, 0o755); err != nil {
-				return fmt.Errorf("%w: failed to create extraction destination %s", cli.ExitCodeCantCreate, extractionDst)
+					fmt.Printf("Alias %s now points to %s\n", a.Name, entry.Version)
+				}
 			}
 
-			if err := node.ExtractArtifact(artifact.Name, extractionDst); err != nil {
-				return fmt.Errorf("%w: failed to extract artifact %s", cli.ExitCodeSoftware, artifact.Name)
+			active, err := node.ReadActiveVersion(c.Paths().Bin())
+			if err != nil {
+				return fmt.Errorf("%w: unable to read bin link: %s", cli.ExitCodeIOErr, err)
 			}
 
-			if len(idx) == 0 || flags.GetBool("use") {
-				if err := os.RemoveAll(c.BinPath()); err != nil {
-					return fmt.Errorf("%w: failed to delete %s", cli.ExitCodeIOErr, c.BinPath())
+			if active == old {
+				if err := activateVersion(c, entry.Version); err != nil {
+					return err
 				}
+			}
 
-				vbin := path.Join(c.VersionsDirPath(), entry.Version, "bin")
-				if err := os.Symlink(vbin, c.BinPath()); err != nil {
-					return fmt.Errorf("%w: failed to symlink %s", cli.ExitCodeIOErr, vbin)
+			if flags.GetBool("remove-old") {
+				if err := removeVersions(c, []string{old}); err != nil {
+					return err
 				}
 			}
 
+			fmt.Printf("Upgraded Node %s to %s\n", old, entry.Version)
 			return nil
 		},
 	})
@@ -190,120 +284,276 @@ func main() {
 	c.AddCommand(&cli.Command{
 		Name:        "remove",
 		Aliases:     []string{"rm"},
-		Description: "Remove a Node version",
-		Usage:       "nvm {rm,remove} <VERSION>",
+		Description: "Remove Node versions",
+		Usage:       "nvm {rm,remove} <VERSION|RANGE...> [OPTIONS]",
+		Flags: []cli.Flag{
+			cli.NewBoolFlagP("all", "a", false, "Remove every installed version"),
+			cli.NewBoolFlagP("yes", "y", false, "Don't ask for confirmation"),
+			cli.NewBoolFlagP("force", "f", false, "Remove the default version too"),
+		},
 		Run: func(args cli.Args, flags cli.FlagSet) error {
-			version := args.Get(0)
-
-			if version == "" {
-				return cli.ExitCodeUsage
+			if flags.GetBool("all") && len(args) > 0 {
+				return fmt.Errorf("%w: --all doesn't take versions", cli.ExitCodeUsage)
 			}
 
-			if !strings.HasPrefix(version, "v") {
-				version = "v" + version
+			if !flags.GetBool("all") && len(args) == 0 {
+				return cli.ExitCodeUsage
 			}
 
-			idx, err := node.GetLocalIndex(c.VersionsDirPath())
+			// lock before resolving, so what's confirmed is what's removed, and the default can't change in between
+			unlock, err := lockState(c)
 			if err != nil {
-				return fmt.Errorf("%w: unable to read local index", cli.ExitCodeIOErr)
+				return err
 			}
+			defer unlock()
 
-			var entry *node.IndexEntry
+			var versions []string
+			// why the removal needs confirming, if it does
+			confirm := ""
 
-			for _, e := range idx {
-				if strings.HasPrefix(e.Version, version) {
-					entry = &e
-					break
+			if flags.GetBool("all") {
+				idx, err := node.GetLocalIndex(c.Paths().Versions())
+				if err != nil {
+					return fmt.Errorf("%w: unable to read local index", cli.ExitCodeIOErr)
 				}
-			}
 
-			if entry == nil {
-				return fmt.Errorf("%w: %s: no such version", cli.ExitCodeUsage, version)
+				for _, e := range idx {
+					versions = append(versions, e.Version)
+				}
+
+				if len(versions) > 1 {
+					confirm = "this removes every installed version"
+				}
+			} else {
+				for _, spec := range args {
+					// e.g. an unset variable in `nvm rm "$V"`, which must not be taken to mean every version
+					if strings.TrimSpace(spec) == "" {
+						return fmt.Errorf("%w: empty version", cli.ExitCodeUsage)
+					}
+
+					matches, err := node.MatchLocal(c.Paths(), spec)
+					if err != nil {
+						if errors.Is(err, node.ErrNotInstalled) || errors.Is(err, node.ErrUnknownVersion) {
+							return fmt.Errorf("%w: %s: no such version", cli.ExitCodeUsage, spec)
+						}
+
+						return fmt.Errorf("%w: unable to read local index", cli.ExitCodeIOErr)
+					}
+
+					if slices.Contains(matches, node.SystemVersion) {
+						return fmt.Errorf("%w: the system Node is not managed by nvm", cli.ExitCodeUsage)
+					}
+
+					// a pattern matching several versions might match more than the user had in mind
+					if len(matches) > 1 {
+						confirm = "multiple versions match"
+					}
+
+					for _, v := range matches {
+						if !slices.Contains(versions, v) {
+							versions = append(versions, v)
+						}
+					}
+				}
 			}
 
-			boundVersionPath, err := os.Readlink(c.BinPath())
-			if err != nil {
-				return fmt.Errorf("%w: unable to read bin link", cli.ExitCodeIOErr)
+			if len(versions) == 0 {
+				fmt.Println("No versions installed")
+				return nil
 			}
 
-			versionPath := path.Join(c.VersionsDirPath(), entry.Version)
-			if err := os.RemoveAll(versionPath); err != nil {
-				return fmt.Errorf("%w: unable to delete %s", cli.ExitCodeIOErr, versionPath)
+			if def, err := node.ResolveLocal(c.Paths(), "default"); err == nil && !flags.GetBool("force") {
+				if slices.Contains(versions, def) {
+					return fmt.Errorf("%w: Node %s is the default version. Point the default alias elsewhere with `nvm alias default <VERSION>`, or use --force", cli.ExitCodeUsage, def)
+				}
 			}
 
-			if path.Dir(boundVersionPath) == versionPath {
-				os.RemoveAll(c.BinPath())
-				fmt.Printf("Node %s was active and has been removed. Run `nvm use <VERSION>` to activate another\n", entry.Version)
+			if confirm != "" && !flags.GetBool("yes") {
+				if err := confirmf(confirm, "Remove %s?", strings.Join(versions, ", ")); err != nil {
+					return err
+				}
 			}
 
-			return nil
+			return removeVersions(c, versions)
 		},
 	})
 
 	c.AddCommand(&cli.Command{
-		Name:        "use",
-		Description: "Activate a version",
-		Usage:       "nvm use <VERSION>",
+		Name:        "prune",
+		Description: "Remove old or unused versions",
+		Usage:       "nvm prune [OPTIONS]",
+		Flags: []cli.Flag{
+			cli.NewStringFlagP("keep", "k", "", "Keep the newest N versions of each major line"),
+			cli.NewBoolFlagP("unused", "", false, "Remove versions not referenced by an alias or a version file used in the last 90 days"),
+			cli.NewBoolFlagP("dry-run", "n", false, "Print what would be removed without removing anything"),
+		},
 		Run: func(args cli.Args, flags cli.FlagSet) error {
-			version := args.Get(0)
+			keep := -1
+			if k := flags.GetString("keep"); k != "" {
+				n, err := strconv.Atoi(k)
+				if err != nil || n < 0 {
+					return fmt.Errorf("%w: --keep must be a non-negative number", cli.ExitCodeUsage)
+				}
+				keep = n
+			}
 
-			if version == "" {
-				wd, err := os.Getwd()
-				if err != nil {
-					return cli.ExitCodeSoftware
+			unused := flags.GetBool("unused")
+			if keep < 0 && !unused {
+				return fmt.Errorf("%w: at least one of --keep or --unused is required", cli.ExitCodeUsage)
+			}
+
+			unlock, err := lockState(c)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			local, err := node.GetLocalIndex(c.Paths().Versions())
+			if err != nil {
+				return fmt.Errorf("%w: unable to read local index: %s", cli.ExitCodeIOErr, err)
+			}
+
+			protected, err := protectedVersions(c)
+			if err != nil {
+				return err
+			}
+
+			var referenced map[string]bool
+			if unused {
+				if referenced, err = referencedVersions(c, time.Now().Add(-versionFileRecency)); err != nil {
+					return err
 				}
+			}
+
+			// newest first, so the first keep versions seen of each major are the ones to keep
+			slices.SortFunc(local, func(a, b node.IndexEntry) int { return semver.Compare(b.Version, a.Version) })
+			seen := make(map[int]int)
 
-				nvmrc := path.Join(wd, ".nvmrc")
-				f, err := os.Open(nvmrc)
+			var pruned []string
+			var reclaimed int64
+			for _, e := range local {
+				v, err := semver.Parse(e.Version)
 				if err != nil {
-					if errors.Is(err, fs.ErrNotExist) {
-						return fmt.Errorf("%w: no .nvmrc in current directory", cli.ExitCodeUsage)
-					}
+					continue
+				}
 
-					return fmt.Errorf("%w: failed to open %s", cli.ExitCodeIOErr, nvmrc)
+				seen[v.Major]++
+
+				// a version is only pruned if every requested policy selects it
+				if keep >= 0 && seen[v.Major] <= keep {
+					continue
+				}
+				if unused && referenced[e.Version] {
+					continue
+				}
+				if protected[e.Version] {
+					continue
 				}
 
-				b, err := io.ReadAll(f)
+				versionPath := c.Paths().Version(e.Version)
+				size, err := platform.DirSize(versionPath)
 				if err != nil {
-					return fmt.Errorf("%w: failed to read %s", cli.ExitCodeIOErr, nvmrc)
+					return fmt.Errorf("%w: unable to read %s: %s", cli.ExitCodeIOErr, versionPath, err)
+				}
+
+				if flags.GetBool("dry-run") {
+					fmt.Printf("Would remove %s (%s)\n", e.Version, formatBytes(size))
+				} else {
+					fmt.Printf("Removing %s (%s)\n", e.Version, formatBytes(size))
 				}
 
-				version = string(bytes.TrimSpace(b))
+				pruned = append(pruned, e.Version)
+				reclaimed += size
 			}
 
-			// TODO: check if version already linked?
+			if !flags.GetBool("dry-run") {
+				if err := removeVersions(c, pruned); err != nil {
+					return err
+				}
+			}
 
-			if !strings.HasPrefix(version, "v") {
-				version = "v" + version
+			switch {
+			case len(pruned) == 0:
+				fmt.Println("Nothing to prune")
+			case flags.GetBool("dry-run"):
+				fmt.Printf("Would reclaim %s\n", formatBytes(reclaimed))
+			default:
+				fmt.Printf("Reclaimed %s\n", formatBytes(reclaimed))
 			}
 
-			idx, err := node.GetLocalIndex(c.VersionsDirPath())
+			return nil
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "use",
+		Description: "Activate a version",
+		Usage:       "nvm use [VERSION] [OPTIONS]",
+		Flags: []cli.Flag{
+			cli.NewBoolFlagP("fail-on-eol", "", false, "Fail if the version has reached end-of-life"),
+			cli.NewBoolFlagP("install-if-missing", "", cfg.AutoInstall, "Install the newest matching release if no installed version matches"),
+		},
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			// a missing version is installed before taking the lock, which a download mustn't hold
+			version, err := resolveOrInstallVersion(c, args.Get(0), flags.GetBool("install-if-missing"))
 			if err != nil {
-				return fmt.Errorf("%w: failed to read local index", cli.ExitCodeIOErr)
+				return err
 			}
 
-			var entry *node.IndexEntry
-			for _, e := range idx {
-				if strings.HasPrefix(e.Version, version) {
-					entry = &e
-					break
+			// the check may fetch the release schedule, so it runs before the lock is taken too, but not after the
+			// version is activated, which --fail-on-eol has to prevent
+			if version != node.SystemVersion {
+				if err := checkEOL(version, flags.GetBool("fail-on-eol")); err != nil {
+					return err
 				}
 			}
 
-			if entry == nil {
-				return fmt.Errorf("%w: no such version: %s", cli.ExitCodeUsage, version)
-			}
+			err = func() error {
+				unlock, err := lockState(c)
+				if err != nil {
+					return err
+				}
+				defer unlock()
+
+				// resolved again, since another process may have changed the installed versions in the meantime
+				version, err := resolveInstalledVersion(c.Paths(), args.Get(0))
+				if err != nil {
+					return err
+				}
+
+				// TODO: check if version already linked?
 
-			if !strings.HasPrefix(version, "v") {
-				version = "v" + version
+				if version == node.SystemVersion {
+					if _, err := systemNodePath(c.Paths()); err != nil {
+						return fmt.Errorf("%w: no system Node found in PATH", cli.ExitCodeUnavailable)
+					}
+				}
+
+				return activateVersion(c, version)
+			}()
+			if err != nil {
+				return err
 			}
 
-			if err := os.RemoveAll(c.BinPath()); err != nil {
-				return fmt.Errorf("%w: failed to remove existing bin", cli.ExitCodeIOErr)
+			// the check may wait on the network, which mustn't hold up other nvm processes
+			notifySecurityUpdates(c)
+			return nil
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "deactivate",
+		Description: "Deactivate the active version, falling back to the system Node if there is one",
+		Usage:       "nvm deactivate",
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			unlock, err := lockState(c)
+			if err != nil {
+				return err
 			}
+			defer unlock()
 
-			if err := os.Symlink(path.Join(c.VersionsDirPath(), entry.Version, "bin"), c.BinPath()); err != nil {
-				return fmt.Errorf("%w: failed to symlink version %s", cli.ExitCodeIOErr, version)
+			if err := os.RemoveAll(c.Paths().Bin()); err != nil {
+				return fmt.Errorf("%w: failed to remove %s", cli.ExitCodeIOErr, c.Paths().Bin())
 			}
 
 			return nil
@@ -314,111 +564,1146 @@ func main() {
 		Name:        "list",
 		Aliases:     []string{"ls"},
 		Description: "List Node versions",
-		Usage:       "nvm {ls,list} [-r,--remote]",
-		Flags: []cli.Flag{
+		Usage:       "nvm {ls,list} [-r,--remote [RANGE] [FILTERS]] [--json] [--format TEMPLATE]",
+		Flags: append([]cli.Flag{
 			cli.NewBoolFlagP("remote", "r", false, "List versions in remote index"),
-		},
+			cli.NewOptionalStringFlagP("lts", "", "", "*", "CODENAME", "Only list LTS releases, or those of the LTS line CODENAME"),
+			cli.NewStringFlagP("since", "", "", "Only list releases made on or after a date, formatted YYYY-MM-DD"),
+			cli.NewBoolFlagP("security-only", "", false, "Only list releases that fix security issues"),
+			cli.NewBoolFlagP("latest-per-major", "", false, "Only list the newest release of each major version"),
+			cli.NewBoolFlagP("available", "", false, "Only list releases with a build for this platform"),
+			cli.NewStringFlagP("limit", "", "", "List at most this many releases, at least 1"),
+			cli.NewStringFlagP("offset", "", "", "Skip this many of the newest releases"),
+		}, outputFlags()...),
 		Run: func(args cli.Args, flags cli.FlagSet) error {
+			structured, err := structuredOutput(flags)
+			if err != nil {
+				return err
+			}
+
+			filter, err := remoteIndexFilter(args, flags)
+			if err != nil {
+				return err
+			}
+
 			var idx []node.IndexEntry
+			latestLTS := make(map[string]bool)
 
 			if flags.GetBool("remote") {
 				ridx, err := node.GetRemoteIndex()
 				if err != nil {
 					return fmt.Errorf("%w: %s", cli.ExitCodeUnavailable, err)
 				}
-				idx = ridx
+
+				// the newest release of an LTS line is followed by a current release, which the filters may remove
+				for i, e := range ridx {
+					latestLTS[e.Version] = e.LTS != "" && ridx[max(i-1, 0)].LTS == ""
+				}
+
+				idx, err = node.FilterIndex(ridx, filter)
+				if err != nil {
+					return fmt.Errorf("%w: %s", cli.ExitCodeUsage, err)
+				}
+
+				if structured {
+					return printStructured(flags, idx, false)
+				}
+			} else if filter != (node.IndexFilter{}) {
+				return fmt.Errorf("%w: filters only apply to --remote", cli.ExitCodeUsage)
+			} else if structured {
+				versions, err := node.ListInstalled(c.Paths())
+				if err != nil {
+					return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
+				}
+
+				return printStructured(flags, versions, false)
 			} else {
-				lidx, err := node.GetLocalIndex(c.VersionsDirPath())
+				// installed versions are annotated with the codename of their LTS line, like in the JSON output
+				versions, err := node.ListInstalled(c.Paths())
 				if err != nil {
 					return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
 				}
-				idx = lidx
+
+				for _, v := range versions {
+					idx = append(idx, node.IndexEntry{Version: v.Version, LTS: v.LTS})
+				}
 			}
 
-			activeVersion, err := os.Readlink(c.BinPath())
+			activeVersion, err := node.ReadActiveVersion(c.Paths().Bin())
 			if err != nil {
-				if !errors.Is(err, fs.ErrNotExist) {
-					return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
-				}
+				return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
 			}
 
-			activeVersion = path.Base(path.Dir(activeVersion))
+			// the schedule is only used to annotate installed versions, so list them without it if it's unavailable
+			var schedule node.Schedule
+			if !flags.GetBool("remote") {
+				schedule, _ = node.GetSchedule()
+			}
+			now := time.Now()
 
-			// TODO: some way of mapping local versions to LTS names
-			// until then, use lts won't be supported
 			for i := len(idx) - 1; i >= 0; i-- {
 				entry := idx[i]
+
+				// the line of the active version is green, apart from annotations with their own style
+				var line []cli.Style
 				if entry.Version == activeVersion {
-					fmt.Printf("\x1b[32m->%13s", entry.Version)
+					line = []cli.Style{cli.Green}
+					fmt.Print(cli.Stdout.Style(fmt.Sprintf("->%13s", entry.Version), line...))
 				} else {
 					fmt.Printf("%15s", entry.Version)
 				}
 
-				isLatestLTS := entry.LTS != "" && idx[max(i-1, 0)].LTS == ""
-
 				if entry.LTS != "" {
-					if isLatestLTS {
-						fmt.Printf("\x1b[1;32m  (Latest LTS: %s)\x1b[0m", entry.LTS)
+					if latestLTS[entry.Version] {
+						fmt.Print(cli.Stdout.Style(fmt.Sprintf("  (Latest LTS: %s)", entry.LTS), cli.Bold, cli.Green))
 					} else {
-						fmt.Printf("  (LTS: %s)", entry.LTS)
+						fmt.Print(cli.Stdout.Style(fmt.Sprintf("  (LTS: %s)", entry.LTS), line...))
+					}
+				}
+
+				if !flags.GetBool("remote") {
+					meta, err := node.ReadMetadata(c.Paths().Version(entry.Version))
+					if err == nil && meta.Npm != nil {
+						fmt.Print(cli.Stdout.Style(fmt.Sprintf("  (npm %s)", meta.Npm.Version), line...))
+					}
+
+					switch phase := schedule.Phase(entry.Version, now); phase {
+					case node.PhaseMaintenance:
+						fmt.Print(cli.Stdout.Style(fmt.Sprintf("  (%s)", phase), cli.Yellow))
+					case node.PhaseEOL:
+						fmt.Print(cli.Stdout.Style(fmt.Sprintf("  (%s)", phase), cli.Red))
 					}
 				}
 
-				fmt.Print("\x1b[0m\n")
+				fmt.Println()
 			}
 
 			return nil
 		},
 	})
 
-	c.Exec()
-}
+	c.AddCommand(&cli.Command{
+		Name:        "alias",
+		Description: "List, show or set version aliases",
+		Usage:       "nvm alias [NAME] [VERSION] [--json] [--format TEMPLATE]",
+		Flags:       outputFlags(),
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			aliasDir := c.Paths().Aliases()
+			name, target := args.Get(0), args.Get(1)
+
+			structured, err := structuredOutput(flags)
+			if err != nil {
+				return err
+			}
 
-var ErrVersionNumber = errors.New("invalid version number")
+			switch {
+			case name == "":
+				aliases, err := node.ResolveAliases(c.Paths())
+				if err != nil {
+					return fmt.Errorf("%w: unable to read aliases: %s", cli.ExitCodeIOErr, err)
+				}
 
-func parseVersion(v string) (major, minor, patch string, err error) {
-	reg := regexp.MustCompile(`\d+(\.\d+)*`)
-	if !reg.MatchString(v) {
-		err = fmt.Errorf("%w: %s", ErrVersionNumber, v)
-		return
-	}
+				if structured {
+					return printStructured(flags, aliases, false)
+				}
 
-	v = strings.TrimPrefix(v, "v")
-	p := strings.Split(v, ".")
+				for _, a := range aliases {
+					resolved := a.Version
+					if resolved == "" {
+						resolved = "not installed"
+					}
 
-	if len(p) == 0 || len(p) > 3 {
-		err = fmt.Errorf("%w: %s", ErrVersionNumber, v)
-		return
-	}
+					fmt.Printf("%s -> %s (%s)\n", a.Name, a.Target, resolved)
+				}
+			case target == "":
+				t, err := node.ReadAlias(aliasDir, name)
+				if err != nil {
+					if errors.Is(err, fs.ErrNotExist) || errors.Is(err, node.ErrInvalidAlias) {
+						return fmt.Errorf("%w: no such alias: %s", cli.ExitCodeUsage, name)
+					}
 
-	major = p[0]
+					return fmt.Errorf("%w: unable to read alias %s: %s", cli.ExitCodeIOErr, name, err)
+				}
 
-	if len(p) > 1 {
-		minor = p[1]
-	}
+				if structured {
+					a := node.ResolvedAlias{Name: name, Target: t}
+					if v, err := node.ResolveLocal(c.Paths(), name); err == nil {
+						a.Version = v
+					}
 
-	if len(p) > 2 {
-		patch = p[2]
-	}
+					return printStructured(flags, []node.ResolvedAlias{a}, true)
+				}
 
-	return
-}
+				fmt.Println(t)
+			default:
+				if _, err := node.ResolveLocal(c.Paths(), target); errors.Is(err, node.ErrUnknownVersion) {
+					return fmt.Errorf("%w: %s", cli.ExitCodeUsage, err)
+				}
+
+				unlock, err := lockState(c)
+				if err != nil {
+					return err
+				}
+				defer unlock()
+
+				if err := node.WriteAlias(aliasDir, name, target); err != nil {
+					if errors.Is(err, node.ErrInvalidAlias) {
+						return fmt.Errorf("%w: %s", cli.ExitCodeUsage, err)
+					}
+
+					return fmt.Errorf("%w: unable to write alias %s: %s", cli.ExitCodeIOErr, name, err)
+				}
+			}
+
+			return nil
+		},
+	})
 
-func versionsMatcheEager(a, b string) bool {
-	rmaj, rmin, rpatch, _ := parseVersion(a)
-	emaj, emin, epatch, _ := parseVersion(b)
+	c.AddCommand(&cli.Command{
+		Name:        "unalias",
+		Description: "Remove a version alias",
+		Usage:       "nvm unalias <NAME>",
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			name := args.Get(0)
+			if name == "" {
+				return cli.ExitCodeUsage
+			}
+
+			unlock, err := lockState(c)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			if err := node.RemoveAlias(c.Paths().Aliases(), name); err != nil {
+				if errors.Is(err, fs.ErrNotExist) || errors.Is(err, node.ErrInvalidAlias) {
+					return fmt.Errorf("%w: no such alias: %s", cli.ExitCodeUsage, name)
+				}
+
+				return fmt.Errorf("%w: unable to remove alias %s: %s", cli.ExitCodeIOErr, name, err)
+			}
+
+			return nil
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "corepack",
+		Description: "Manage corepack package managers of a version",
+		Usage:       "nvm corepack <COMMAND> [OPTIONS]",
+		Commands: []*cli.Command{
+			{
+				Name:        "enable",
+				Description: "Install package manager shims into a version's bin directory",
+				Usage:       "nvm corepack enable [PM...] [--node <VERSION>]",
+				Flags: []cli.Flag{
+					cli.NewStringFlagP("node", "n", "", "Node version to use instead of the active one"),
+				},
+				Run: func(args cli.Args, flags cli.FlagSet) error {
+					// the version mustn't be removed or replaced while shims are installed into it
+					unlock, err := lockState(c)
+					if err != nil {
+						return err
+					}
+					defer unlock()
+
+					version, err := versionOrActive(c, flags.GetString("node"))
+					if err != nil {
+						return err
+					}
+
+					if err := node.EnableCorepack(node.ExecRunner{}, c.Paths().Version(version), args...); err != nil {
+						return fmt.Errorf("%w: %s", cli.ExitCodeSoftware, err)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:        "prepare",
+				Description: "Download package managers and make them the default",
+				Usage:       "nvm corepack prepare <PM@VERSION...> [--node <VERSION>]",
+				Flags: []cli.Flag{
+					cli.NewStringFlagP("node", "n", "", "Node version to use instead of the active one"),
+				},
+				Run: func(args cli.Args, flags cli.FlagSet) error {
+					if len(args) == 0 {
+						return fmt.Errorf("%w: a package manager is required, e.g. pnpm@9", cli.ExitCodeUsage)
+					}
+
+					unlock, err := lockState(c)
+					if err != nil {
+						return err
+					}
+					defer unlock()
+
+					version, err := versionOrActive(c, flags.GetString("node"))
+					if err != nil {
+						return err
+					}
+
+					versionDir := c.Paths().Version(version)
+
+					// shims are what make the prepared package managers callable, so make sure they're there
+					if err := node.EnableCorepack(node.ExecRunner{}, versionDir); err != nil {
+						return fmt.Errorf("%w: %s", cli.ExitCodeSoftware, err)
+					}
+
+					if err := node.PrepareCorepack(node.ExecRunner{}, versionDir, args...); err != nil {
+						return fmt.Errorf("%w: %s", cli.ExitCodeSoftware, err)
+					}
+
+					return nil
+				},
+			},
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "config",
+		Description: "Show or change settings",
+		Usage:       "nvm config <COMMAND>",
+		Commands: []*cli.Command{
+			{
+				Name:        "list",
+				Aliases:     []string{"ls"},
+				Description: "List every setting and its current value",
+				Usage:       "nvm config {ls,list}",
+				Run: func(args cli.Args, flags cli.FlagSet) error {
+					fmt.Printf("# %s\n", c.Paths().ConfigFile)
+
+					for _, key := range config.Keys() {
+						v, _ := cfg.Get(key)
+						if env := config.Overridden(key); env != "" {
+							fmt.Printf("%s = %s (from %s)\n", key, v, env)
+						} else {
+							fmt.Printf("%s = %s\n", key, v)
+						}
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:        "get",
+				Description: "Print the current value of a setting",
+				Usage:       "nvm config get <KEY>",
+				Run: func(args cli.Args, flags cli.FlagSet) error {
+					v, err := cfg.Get(args.Get(0))
+					if err != nil {
+						return fmt.Errorf("%w: %s. Run `nvm config list` for the available settings", cli.ExitCodeUsage, err)
+					}
+
+					fmt.Println(v)
+					return nil
+				},
+			},
+			{
+				Name:        "path",
+				Description: "Print the path of the config file",
+				Usage:       "nvm config path",
+				Run: func(args cli.Args, flags cli.FlagSet) error {
+					fmt.Println(c.Paths().ConfigFile)
+					return nil
+				},
+			},
+			{
+				Name:        "set",
+				Description: "Change a setting in the config file",
+				Usage:       "nvm config set <KEY> <VALUE>",
+				Run: func(args cli.Args, flags cli.FlagSet) error {
+					if len(args) != 2 {
+						return cli.ExitCodeUsage
+					}
+
+					return writeConfig(c, args.Get(0), args.Get(1))
+				},
+			},
+			{
+				Name:        "unset",
+				Description: "Revert a setting to its default",
+				Usage:       "nvm config unset <KEY>",
+				Run: func(args cli.Args, flags cli.FlagSet) error {
+					if len(args) != 1 {
+						return cli.ExitCodeUsage
+					}
+
+					return writeConfig(c, args.Get(0), "")
+				},
+			},
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "npm",
+		Description: "Install a specific npm version into a Node version",
+		Usage:       "nvm npm <RANGE> [--node <VERSION>]",
+		Flags: []cli.Flag{
+			cli.NewStringFlagP("node", "n", "", "Node version to use instead of the active one"),
+		},
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			npmRange := args.Get(0)
+			if npmRange == "" {
+				return fmt.Errorf("%w: an npm version range is required", cli.ExitCodeUsage)
+			}
+
+			if npmRange != "latest" {
+				if _, err := semver.ParseRange(npmRange); err != nil {
+					return fmt.Errorf("%w: %s", cli.ExitCodeUsage, err)
+				}
+			}
+
+			// the version mustn't be removed or replaced while npm is being installed into it
+			unlock, err := lockState(c)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			version, err := versionOrActive(c, flags.GetString("node"))
+			if err != nil {
+				return err
+			}
+
+			npmVersion, err := node.InstallNpm(node.ExecRunner{}, c.Paths().Version(version), npmRange)
+			if err != nil {
+				return fmt.Errorf("%w: failed to install npm@%s: %s", cli.ExitCodeSoftware, npmRange, err)
+			}
+
+			fmt.Printf("Installed npm %s into Node %s\n", npmVersion, version)
+			return nil
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "exec",
+		Description: "Run a command with a Node version's bin directory prepended to PATH",
+		Usage:       "nvm exec [OPTIONS] [VERSION] [--] <COMMAND> [ARGS...]",
+		Flags: []cli.Flag{
+			cli.NewBoolFlagP("install-if-missing", "", cfg.AutoInstall, "Install the newest matching release if no installed version matches"),
+		},
+		Passthrough: 1,
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			spec, argv := splitVersionArg(args)
+			if len(argv) == 0 {
+				return fmt.Errorf("%w: a command is required", cli.ExitCodeUsage)
+			}
+
+			version, err := resolveOrInstallVersion(c, spec, flags.GetBool("install-if-missing"))
+			if err != nil {
+				return err
+			}
+
+			return execWithVersion(c.Paths(), version, argv)
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "run",
+		Description: "Run node using a specific Node version",
+		Usage:       "nvm run [OPTIONS] [VERSION] [--] [SCRIPT] [ARGS...]",
+		Flags: []cli.Flag{
+			cli.NewBoolFlagP("install-if-missing", "", cfg.AutoInstall, "Install the newest matching release if no installed version matches"),
+		},
+		Passthrough: 1,
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			spec, argv := splitVersionArg(args)
+
+			version, err := resolveOrInstallVersion(c, spec, flags.GetBool("install-if-missing"))
+			if err != nil {
+				return err
+			}
+
+			return execWithVersion(c.Paths(), version, append([]string{"node"}, argv...))
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "outdated",
+		Description: "Compare installed versions with the newest releases in their major lines",
+		Usage:       "nvm outdated [--json]",
+		Flags: []cli.Flag{
+			cli.NewBoolFlagP("json", "", false, "Print the report as JSON"),
+		},
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			local, err := node.GetLocalIndex(c.Paths().Versions())
+			if err != nil {
+				return fmt.Errorf("%w: unable to read local index: %s", cli.ExitCodeIOErr, err)
+			}
+
+			remote, err := node.GetRemoteIndex()
+			if err != nil {
+				return fmt.Errorf("%w: unable to retrieve node distribution index: %s", cli.ExitCodeUnavailable, err)
+			}
+
+			schedule, err := node.GetSchedule()
+			if err != nil {
+				return fmt.Errorf("%w: unable to retrieve node release schedule: %s", cli.ExitCodeUnavailable, err)
+			}
+
+			now := time.Now()
+			statuses := make([]node.ReleaseStatus, 0, len(local))
+			for _, e := range local {
+				status := node.GetReleaseStatus(e.Version, remote)
+				status.SetEOL(schedule, now)
+				statuses = append(statuses, status)
+			}
+
+			if flags.GetBool("json") {
+				return printJSON(statuses)
+			}
+
+			fmt.Printf("%-12s %-12s %-10s %-4s %s\n", "VERSION", "LATEST", "LTS", "EOL", "SECURITY")
+			for _, s := range statuses {
+				latest := s.Latest
+				if !s.Outdated {
+					latest = "up to date"
+				}
+
+				lts, security := s.LTS, s.Security
+				if lts == "" {
+					lts = "-"
+				}
+				if security == "" {
+					security = "-"
+				}
+
+				// a line missing from the schedule isn't known to be supported
+				eol := "-"
+				if s.EOL != nil {
+					eol = "no"
+					if *s.EOL {
+						eol = "yes"
+					}
+				}
+
+				fmt.Printf("%-12s %-12s %-10s %-4s %s\n", s.Version, latest, lts, eol, security)
+			}
+
+			return nil
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "current",
+		Description: "Print the active Node version",
+		Usage:       "nvm current [--json] [--format TEMPLATE]",
+		Flags:       outputFlags(),
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			structured, err := structuredOutput(flags)
+			if err != nil {
+				return err
+			}
+
+			version, err := node.ReadActiveVersion(c.Paths().Bin())
+			if err != nil {
+				return fmt.Errorf("%w: unable to read bin link: %s", cli.ExitCodeIOErr, err)
+			}
+
+			if structured {
+				var current []node.InstalledVersion
+
+				if version != "" {
+					v, err := node.GetInstalled(c.Paths(), version)
+					if err != nil {
+						return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
+					}
+					current = append(current, v)
+				} else if nodePath, err := systemNodePath(c.Paths()); err == nil {
+					current = append(current, systemVersion(nodePath, true))
+				}
+
+				return printStructured(flags, current, true)
+			}
+
+			if version == "" {
+				if _, err := systemNodePath(c.Paths()); err == nil {
+					version = node.SystemVersion
+				} else {
+					version = "none"
+				}
+			}
+
+			fmt.Println(version)
+			return nil
+		},
+	})
+
+	c.AddCommand(&cli.Command{
+		Name:        "which",
+		Description: "Print the path to the node executable of a version",
+		Usage:       "nvm which [VERSION] [--json] [--format TEMPLATE]",
+		Flags:       outputFlags(),
+		Run: func(args cli.Args, flags cli.FlagSet) error {
+			structured, err := structuredOutput(flags)
+			if err != nil {
+				return err
+			}
+
+			var version string
+
+			if spec := args.Get(0); spec != "" {
+				if version, err = resolveInstalledVersion(c.Paths(), spec); err != nil {
+					return err
+				}
+			} else {
+				if version, err = node.ReadActiveVersion(c.Paths().Bin()); err != nil {
+					return fmt.Errorf("%w: unable to read bin link: %s", cli.ExitCodeIOErr, err)
+				}
+
+				if version == "" {
+					version = node.SystemVersion
+				}
+			}
+
+			if version == node.SystemVersion {
+				nodePath, err := systemNodePath(c.Paths())
+				if err != nil {
+					return fmt.Errorf("%w: no active version and no system Node found in PATH", cli.ExitCodeUnavailable)
+				}
+
+				if structured {
+					active, err := node.ReadActiveVersion(c.Paths().Bin())
+					if err != nil {
+						return fmt.Errorf("%w: unable to read bin link: %s", cli.ExitCodeIOErr, err)
+					}
+
+					return printStructured(flags, []node.InstalledVersion{systemVersion(nodePath, active == "")}, true)
+				}
+
+				fmt.Println(nodePath)
+				return nil
+			}
+
+			nodePath, err := filepath.Abs(path.Join(c.Paths().Version(version), "bin", "node"))
+			if err != nil {
+				return fmt.Errorf("%w: %s", cli.ExitCodeOSErr, err)
+			}
+
+			if _, err := os.Stat(nodePath); err != nil {
+				return fmt.Errorf("%w: %s", cli.ExitCodeNoInput, err)
+			}
+
+			if structured {
+				v, err := node.GetInstalled(c.Paths(), version)
+				if err != nil {
+					return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
+				}
+
+				return printStructured(flags, []node.InstalledVersion{v}, true)
+			}
+
+			fmt.Println(nodePath)
+			return nil
+		},
+	})
+
+	if cfgErr != nil {
+		for _, cmd := range c.RootCmd.Commands {
+			requireConfig(cmd, cmd.Name == "config")
+		}
+	}
+
+	c.Exec()
+}
+
+// checkEOL warns if version is in maintenance or past end-of-life. If failOnEOL is set, versions past end-of-life
+// are an error.
+func checkEOL(version string, failOnEOL bool) error {
+	schedule, err := node.GetSchedule()
+	if err != nil {
+		// use may run on every prompt, so only nag about it when the check was asked for
+		if failOnEOL {
+			fmt.Fprintf(os.Stderr, "Warning: unable to check end-of-life status of Node %s: %s\n", version, err)
+		} else {
+			slog.Debug("unable to check end-of-life status", "version", version, "error", err)
+		}
+
+		return nil
+	}
+
+	line, ok := schedule.Line(version)
+	if !ok {
+		return nil
+	}
+
+	switch line.Phase(time.Now()) {
+	case node.PhaseMaintenance:
+		fmt.Fprintf(os.Stderr, "Warning: Node %s is in maintenance and only receives critical fixes until %s\n", version, line.End.Format(time.DateOnly))
+	case node.PhaseEOL:
+		if failOnEOL {
+			return fmt.Errorf("%w: Node %s reached end-of-life on %s", cli.ExitCodeDataErr, version, line.End.Format(time.DateOnly))
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: Node %s reached end-of-life on %s and no longer receives security fixes\n", version, line.End.Format(time.DateOnly))
+	}
+
+	return nil
+}
+
+// securityCheckInterval is the minimum time between two checks for security releases of installed versions.
+const securityCheckInterval = 24 * time.Hour
+
+// notifySecurityUpdates prints a notice if there are security releases newer than the installed versions. It's
+// meant to run after commands that may be called on every shell prompt, so it only checks once every
+// securityCheckInterval, and failures are silent.
+func notifySecurityUpdates(c *cli.Cli) {
+	stamp := c.Paths().SecurityCheck()
+	if info, err := os.Stat(stamp); err == nil && time.Since(info.ModTime()) < securityCheckInterval {
+		return
+	}
+
+	local, err := node.GetLocalIndex(c.Paths().Versions())
+	if err != nil || len(local) == 0 {
+		return
+	}
+
+	// the timestamp's mtime is what matters, its content is just for whoever looks at it. It's written before
+	// fetching, so being offline costs one timed out request per interval rather than one per command.
+	os.WriteFile(stamp, []byte(time.Now().Format(time.RFC3339)+"\n"), 0o644)
+
+	remote, err := node.GetRemoteIndex()
+	if err != nil {
+		slog.Debug("unable to check for security releases", "error", err)
+		return
+	}
+
+	updates := node.SecurityUpdates(local, remote)
+	if len(updates) == 0 {
+		return
+	}
+
+	notices := make([]string, len(updates))
+	for i, u := range updates {
+		notices[i] = fmt.Sprintf("%s (installed: %s)", u.Security, u.Version)
+	}
+
+	fmt.Fprintf(os.Stderr, "Security releases available: %s. Run `nvm upgrade <MAJOR>` to update\n", strings.Join(notices, ", "))
+}
+
+// removeVersions deletes installed versions. If the active version is among them, it's deactivated. Aliases that
+// resolved to a removed version are removed if they pointed to that exact version, and reported otherwise.
+func removeVersions(c *cli.Cli, versions []string) error {
+	active, err := node.ReadActiveVersion(c.Paths().Bin())
+	if err != nil {
+		return fmt.Errorf("%w: unable to read bin link: %s", cli.ExitCodeIOErr, err)
+	}
+
+	aliasDir := c.Paths().Aliases()
+	aliases, err := node.ListAliases(aliasDir)
+	if err != nil {
+		return fmt.Errorf("%w: unable to read aliases: %s", cli.ExitCodeIOErr, err)
+	}
+
+	// resolve aliases before anything is removed, to tell which ones are affected
+	affected := make(map[string]string)
+	for _, a := range aliases {
+		if v, err := node.ResolveLocal(c.Paths(), a.Name); err == nil && slices.Contains(versions, v) {
+			affected[a.Name] = v
+		}
+	}
+
+	for _, version := range versions {
+		versionPath := c.Paths().Version(version)
+		if err := os.RemoveAll(versionPath); err != nil {
+			return fmt.Errorf("%w: unable to delete %s", cli.ExitCodeIOErr, versionPath)
+		}
+
+		if version == active {
+			if err := os.RemoveAll(c.Paths().Bin()); err != nil {
+				return fmt.Errorf("%w: unable to delete %s", cli.ExitCodeIOErr, c.Paths().Bin())
+			}
+
+			fmt.Printf("Node %s was active and has been removed. Run `nvm use <VERSION>` to activate another\n", version)
+		}
+	}
+
+	for _, a := range aliases {
+		removed, ok := affected[a.Name]
+		if !ok {
+			continue
+		}
+
+		v, err := node.ResolveLocal(c.Paths(), a.Name)
+		switch {
+		case err == nil:
+			fmt.Printf("Alias %s now resolves to %s\n", a.Name, v)
+		case !errors.Is(err, node.ErrNotInstalled):
+			continue
+		case semver.Compare(a.Target, removed) == 0:
+			if err := node.RemoveAlias(aliasDir, a.Name); err != nil {
+				return fmt.Errorf("%w: unable to remove alias %s: %s", cli.ExitCodeIOErr, a.Name, err)
+			}
+
+			fmt.Printf("Removed alias %s, which pointed to %s\n", a.Name, removed)
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: alias %s (%s) no longer resolves to an installed version\n", a.Name, a.Target)
+		}
+	}
+
+	return nil
+}
+
+// confirmf asks the user a yes/no question on the terminal, and returns an error unless they answer yes. Without a
+// terminal to ask on, it fails with reason, why confirmation is needed, rather than assuming an answer.
+func confirmf(reason, format string, a ...any) error {
+	if !platform.IsTerminal(os.Stdin) {
+		return fmt.Errorf("%w: %s; re-run with --yes", cli.ExitCodeUsage, reason)
+	}
+
+	fmt.Printf(format+" [y/N] ", a...)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
+	}
+
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		// not an error as such, but a script checking the exit code mustn't take it for success
+		return fmt.Errorf("%w: aborted", cli.ExitCodeTempFail)
+	}
+
+	return nil
+}
+
+// requireConfig makes cmd and its subcommands fail with cfgErr, the error loading the config. With warnOnly, they
+// only warn about it instead, so the config commands can still be used to repair the config.
+func requireConfig(cmd *cli.Command, warnOnly bool) {
+	for _, sub := range cmd.Commands {
+		requireConfig(sub, warnOnly)
+	}
+
+	run := cmd.Run
+	if run == nil {
+		return
+	}
+
+	cmd.Run = func(args cli.Args, flags cli.FlagSet) error {
+		if !warnOnly {
+			return fmt.Errorf("%w: %s\nRun `nvm config unset <KEY>` to revert a setting to its default", cli.ExitCodeConfig, cfgErr)
+		}
+
+		fmt.Fprintln(os.Stderr, "Warning:", cfgErr)
+		return run(args, flags)
+	}
+}
+
+// writeConfig sets key to value in the config file, or removes it from the file if value is empty.
+func writeConfig(c *cli.Cli, key, value string) error {
+	unlock, err := lockState(c)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p := c.Paths().ConfigFile
+	if value == "" {
+		err = config.RemoveValue(p, key)
+	} else {
+		err = config.WriteValue(p, key, value)
+	}
+
+	switch {
+	case errors.Is(err, config.ErrUnknownKey), errors.Is(err, config.ErrInvalidValue):
+		return fmt.Errorf("%w: %s", cli.ExitCodeUsage, err)
+	case errors.Is(err, config.ErrSyntax):
+		return fmt.Errorf("%w: %s", cli.ExitCodeConfig, err)
+	case err != nil:
+		return fmt.Errorf("%w: unable to write %s: %s", cli.ExitCodeCantCreate, p, err)
+	}
+
+	if env := config.Overridden(key); env != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s is set and takes precedence over the config file\n", env)
+	}
+
+	return nil
+}
+
+// stateLockTimeout is how long a command waits for another nvm process to release the lock on NVMDIR.
+const stateLockTimeout = 30 * time.Second
+
+// lockState takes the lock that serializes the commands changing NVMDIR, e.g. the installed versions, the bin
+// link, aliases and the lts files. The returned function releases it.
+func lockState(c *cli.Cli) (func(), error) {
+	l, err := platform.AcquireLock(c.Paths().Lock(), stateLockTimeout)
+	if err != nil {
+		var locked *platform.LockedError
+		if errors.As(err, &locked) {
+			if locked.PID > 0 {
+				return nil, fmt.Errorf("%w: another nvm process holds the lock (pid %d)", cli.ExitCodeTempFail, locked.PID)
+			}
+
+			return nil, fmt.Errorf("%w: another nvm process holds the lock", cli.ExitCodeTempFail)
+		}
+
+		return nil, fmt.Errorf("%w: unable to lock %s: %s", cli.ExitCodeIOErr, c.Paths().Root, err)
+	}
+
+	return func() { l.Unlock() }, nil
+}
+
+// versionFileRecency is how recently a version file must have been used for the versions it references to count
+// as used by prune.
+const versionFileRecency = 90 * 24 * time.Hour
+
+// protectedVersions returns the installed versions that must never be removed implicitly: the active version and
+// the version the default alias resolves to.
+func protectedVersions(c *cli.Cli) (map[string]bool, error) {
+	protected := make(map[string]bool)
+
+	active, err := node.ReadActiveVersion(c.Paths().Bin())
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read bin link: %s", cli.ExitCodeIOErr, err)
+	}
+
+	if active != "" {
+		protected[active] = true
+	}
+
+	if v, err := node.ResolveLocal(c.Paths(), "default"); err == nil {
+		protected[v] = true
+	}
+
+	return protected, nil
+}
+
+// referencedVersions returns the installed versions that aliases, or version files used after since, resolve to.
+func referencedVersions(c *cli.Cli, since time.Time) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+
+	aliases, err := node.ListAliases(c.Paths().Aliases())
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read aliases: %s", cli.ExitCodeIOErr, err)
+	}
+
+	for _, a := range aliases {
+		if v, err := node.ResolveLocal(c.Paths(), a.Name); err == nil {
+			referenced[v] = true
+		}
+	}
+
+	versionFiles, err := node.RecentVersionFiles(c.Paths().VersionFileHistory(), since)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read version file history: %s", cli.ExitCodeIOErr, err)
+	}
+
+	for _, vf := range versionFiles {
+		spec, err := node.ReadVersionFile(vf)
+		if err != nil {
+			continue
+		}
+
+		if v, err := node.ResolveLocal(c.Paths(), spec); err == nil {
+			referenced[v] = true
+		}
+	}
+
+	return referenced, nil
+}
+
+// formatBytes formats n bytes with a binary unit, e.g. "45.3 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// versionOrActive resolves spec to an installed version, or returns the active version if spec is empty. The
+// system Node isn't managed by nvm, so it's rejected.
+func versionOrActive(c *cli.Cli, spec string) (string, error) {
+	var version string
+	var err error
+
+	if spec != "" {
+		version, err = resolveInstalledVersion(c.Paths(), spec)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if version, err = node.ReadActiveVersion(c.Paths().Bin()); err != nil {
+			return "", fmt.Errorf("%w: unable to read bin link: %s", cli.ExitCodeIOErr, err)
+		}
+
+		if version == "" {
+			return "", fmt.Errorf("%w: no active version", cli.ExitCodeUnavailable)
+		}
+	}
+
+	if version == node.SystemVersion {
+		return "", fmt.Errorf("%w: the system Node is not managed by nvm", cli.ExitCodeUsage)
+	}
+
+	return version, nil
+}
+
+// remoteIndexFilter returns the filter of the remote index selected by the arguments and flags of ls.
+func remoteIndexFilter(args cli.Args, flags cli.FlagSet) (node.IndexFilter, error) {
+	filter := node.IndexFilter{
+		LTS:            flags.GetString("lts"),
+		Range:          args.Get(0),
+		Since:          flags.GetString("since"),
+		SecurityOnly:   flags.GetBool("security-only"),
+		LatestPerMajor: flags.GetBool("latest-per-major"),
+	}
+
+	if flags.GetBool("available") {
+		hostOS, hostArch := platform.SysInfoNorm()
+		if cfg.Arch != "" {
+			hostArch = cfg.Arch
+		}
+
+		filter.Platform = node.PlatformFile(hostOS, hostArch)
+	}
+
+	// a codename after a bare --lts is taken as the range, since --lts only takes a value attached with "="
+	if filter.LTS == "*" && filter.Range != "" {
+		if _, err := semver.ParseRange(filter.Range); err != nil {
+			return node.IndexFilter{}, fmt.Errorf("%w: %s. To list an LTS line, use --lts=%s", cli.ExitCodeUsage, err, filter.Range)
+		}
+	}
+
+	for _, n := range []struct {
+		flag string
+		dst  *int
+		// min is the smallest value allowed
+		min int
+	}{
+		// --limit 0 would list nothing, rather than everything as the zero filter does
+		{"limit", &filter.Limit, 1},
+		{"offset", &filter.Offset, 0},
+	} {
+		v := flags.GetString(n.flag)
+		if v == "" {
+			continue
+		}
+
+		i, err := strconv.Atoi(v)
+		if err != nil || i < n.min {
+			return node.IndexFilter{}, fmt.Errorf("%w: --%s must be a number of at least %d", cli.ExitCodeUsage, n.flag, n.min)
+		}
+
+		*n.dst = i
+	}
+
+	return filter, nil
+}
+
+// systemVersion describes the system Node, whose node executable is at nodePath, in the schema of installed
+// versions. It's active if no installed version is.
+func systemVersion(nodePath string, active bool) node.InstalledVersion {
+	return node.InstalledVersion{
+		Version: node.SystemVersion,
+		Active:  active,
+		Aliases: []string{},
+		Node:    nodePath,
+	}
+}
+
+// systemNodePath returns the path of the first node executable in PATH that isn't managed by nvm.
+func systemNodePath(p paths.Paths) (string, error) {
+	return platform.LookPathIn("node", platform.PathExcluding(p.Root))
+}
+
+// splitVersionArg separates the optional leading version argument of exec and run from the command line that
+// follows it. A leading "--" means no version was given.
+func splitVersionArg(args cli.Args) (spec string, argv []string) {
+	argv = args
+	if len(argv) > 0 && argv[0] != "--" {
+		spec, argv = argv[0], argv[1:]
+	}
+
+	if len(argv) > 0 && argv[0] == "--" {
+		argv = argv[1:]
+	}
+
+	return spec, argv
+}
+
+// resolveInstalledVersion resolves spec to an installed version. An empty spec is read from the nearest version
+// file, starting in the working directory. If no installed version matches, the returned error wraps
+// [node.ErrNotInstalled] or [node.ErrUnknownVersion].
+func resolveInstalledVersion(p paths.Paths, spec string) (string, error) {
+	spec, err := versionSpec(p, spec)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := node.ResolveLocal(p, spec)
+	if err != nil {
+		if errors.Is(err, node.ErrNotInstalled) || errors.Is(err, node.ErrUnknownVersion) {
+			return "", fmt.Errorf("%w: %w", cli.ExitCodeUsage, err)
+		}
+
+		return "", fmt.Errorf("%w: failed to read local index: %s", cli.ExitCodeIOErr, err)
+	}
+
+	return version, nil
+}
+
+// versionSpec returns spec, or the spec in the nearest version file if it's empty.
+func versionSpec(p paths.Paths, spec string) (string, error) {
+	if spec != "" {
+		return spec, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", cli.ExitCodeSoftware
+	}
+
+	vf, err := node.FindVersionFile(wd)
+	if err != nil {
+		if errors.Is(err, node.ErrNoVersionFile) {
+			return "", fmt.Errorf("%w: no version given and no version file found", cli.ExitCodeUsage)
+		}
+
+		return "", fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
+	}
+
+	if spec, err = node.ReadVersionFile(vf); err != nil {
+		return "", fmt.Errorf("%w: failed to read %s: %s", cli.ExitCodeIOErr, vf, err)
+	}
+
+	// only used to tell which versions projects depend on, so it's not worth failing over
+	node.RecordVersionFile(p.VersionFileHistory(), vf)
+
+	return spec, nil
+}
+
+// isMissingVersion reports whether err, returned by resolveInstalledVersion, means no installed version matched.
+func isMissingVersion(err error) bool {
+	return errors.Is(err, node.ErrNotInstalled) || errors.Is(err, node.ErrUnknownVersion)
+}
+
+// execWithVersion replaces the current process with argv, run with the bin directory of version first in PATH. For
+// the system version, every entry inside the nvm root is removed from PATH instead.
+func execWithVersion(p paths.Paths, version string, argv []string) error {
+	pathEnv := platform.PathExcluding(p.Root)
+	if version != node.SystemVersion {
+		pathEnv = path.Join(p.Version(version), "bin") + string(os.PathListSeparator) + os.Getenv("PATH")
+	}
 
-	if rmaj != emaj {
-		return false
+	if err := os.Setenv("PATH", pathEnv); err != nil {
+		return fmt.Errorf("%w: failed to set PATH", cli.ExitCodeOSErr)
 	}
 
-	if rmin != "" && rmin != emin {
-		return false
+	bin, err := exec.LookPath(argv[0])
+	if err != nil {
+		return fmt.Errorf("%w: command not found: %s", cli.ExitCodeUnavailable, argv[0])
 	}
 
-	if rpatch != "" && rpatch != epatch {
-		return false
+	if err := platform.Exec(bin, argv, os.Environ()); err != nil {
+		return fmt.Errorf("%w: failed to execute %s: %s", cli.ExitCodeOSErr, argv[0], err)
 	}
 
-	return true
+	return nil
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/aronhoyer/go-nvm/internal/node"
+	"github.com/aronhoyer/go-nvm/internal/paths"
+	"github.com/aronhoyer/go-nvm/internal/platform"
+)
+
+// migrationMarker is created in the legacy directory while a migration is in progress, so one that fails partway is
+// picked up again by the next command rather than leaving the state split between both directories.
+const migrationMarker = ".migrating"
+
+// migrateLegacyDir moves the installed versions, aliases, settings and history in p.Legacy, the ~/.nvm directory
+// that was used before the XDG directories were supported, to p. It only does so if the legacy directory holds
+// versions and p holds none yet, or if a previous migration didn't finish. The directory of nvm-sh, which defaults
+// to the same path, is left alone.
+func migrateLegacyDir(p paths.Paths) error {
+	legacy := p.Legacy
+	marker := path.Join(legacy, migrationMarker)
+
+	if _, err := os.Stat(marker); err != nil {
+		if _, err := os.Stat(path.Join(legacy, "nvm.sh")); err == nil {
+			return nil
+		}
+
+		if _, err := os.Stat(p.Versions()); err == nil {
+			return nil
+		}
+
+		idx, err := node.GetLocalIndex(path.Join(legacy, "versions"))
+		if err != nil || len(idx) == 0 {
+			return nil
+		}
+
+		if err := os.WriteFile(marker, nil, 0o644); err != nil {
+			return fmt.Errorf("unable to start migration: %w. Set NVMDIR=%s to keep using it as is", err, legacy)
+		}
+	}
+
+	active, err := node.ReadActiveVersion(path.Join(legacy, "bin"))
+	if err != nil {
+		return fmt.Errorf("unable to read bin link: %w", err)
+	}
+
+	moves := []struct{ src, dst string }{
+		{path.Join(legacy, "versions"), p.Versions()},
+		{path.Join(legacy, "alias"), p.Aliases()},
+		{path.Join(legacy, "lts"), p.LTS()},
+		{path.Join(legacy, "default-packages"), p.DefaultPackages()},
+		{path.Join(legacy, path.Base(p.ConfigFile)), p.ConfigFile},
+		{path.Join(legacy, "version-files"), p.VersionFileHistory()},
+		{path.Join(legacy, "security-check"), p.SecurityCheck()},
+	}
+
+	for _, m := range moves {
+		if _, err := os.Lstat(m.src); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if _, err := os.Lstat(m.dst); err == nil {
+			continue
+		}
+
+		if err := os.MkdirAll(path.Dir(m.dst), 0o755); err != nil {
+			return err
+		}
+
+		if err := os.Rename(m.src, m.dst); err != nil {
+			return fmt.Errorf("unable to move %s to %s: %w. Set NVMDIR=%s to keep using it as is", m.src, m.dst, err, legacy)
+		}
+	}
+
+	// the link is absolute, so it has to be recreated to point into the new versions directory. The old one is only
+	// removed once that's done, so a retry still knows which version was active.
+	if active != "" {
+		if err := platform.SymlinkForce(path.Join(p.Version(active), "bin"), p.Bin()); err != nil {
+			return fmt.Errorf("unable to link %s: %w", active, err)
+		}
+	}
+	os.Remove(path.Join(legacy, "bin"))
+
+	// older releases linked the latest LTS line by its absolute path, which still points into the legacy directory
+	latest := path.Join(p.LTS(), "latest")
+	if target, err := os.Readlink(latest); err == nil && path.IsAbs(target) {
+		if err := platform.SymlinkForce(path.Base(target), latest); err != nil {
+			return fmt.Errorf("unable to link the latest LTS line: %w", err)
+		}
+	}
+
+	// the cache is rebuilt on demand
+	os.RemoveAll(path.Join(legacy, "cache"))
+	os.Remove(path.Join(legacy, "nvm.lock"))
+
+	if err := os.Remove(marker); err != nil {
+		return fmt.Errorf("unable to finish migration: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Moved installed versions and settings from %s to %s\n", legacy, p.Root)
+	fmt.Fprintf(os.Stderr, "Make sure %s is in your PATH, e.g. by sourcing the env.sh of the latest release\n", p.Bin())
+
+	return nil
+}
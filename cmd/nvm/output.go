@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/aronhoyer/go-nvm/internal/cli"
+)
+
+// outputFlags are the flags of the read-only commands that can print their output for scripts instead of people.
+func outputFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.NewBoolFlagP("json", "", false, "Print the output as JSON"),
+		cli.NewStringFlagP("format", "", "", "Print each item with a Go template, e.g. '{{.Version}}'"),
+	}
+}
+
+// structuredOutput reports whether --json or --format was given. They're mutually exclusive.
+func structuredOutput(flags cli.FlagSet) (bool, error) {
+	asJSON, format := flags.GetBool("json"), flags.GetString("format")
+	if asJSON && format != "" {
+		return false, fmt.Errorf("%w: --json and --format are mutually exclusive", cli.ExitCodeUsage)
+	}
+
+	return asJSON || format != "", nil
+}
+
+// printStructured prints items as a JSON array, or each item with the --format template. If single is set, the
+// JSON output is the only item, or null if there is none, instead of an array.
+func printStructured[T any](flags cli.FlagSet, items []T, single bool) error {
+	if format := flags.GetString("format"); format != "" {
+		return printFormat(format, items)
+	}
+
+	if !single {
+		return printJSON(items)
+	}
+
+	if len(items) == 0 {
+		return printJSON(nil)
+	}
+
+	return printJSON(items[0])
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("%w: %s", cli.ExitCodeIOErr, err)
+	}
+
+	return nil
+}
+
+// printFormat prints each item with the text/template format, followed by a newline.
+func printFormat[T any](format string, items []T) error {
+	tmpl, err := template.New("format").Funcs(template.FuncMap{"join": strings.Join}).Parse(format)
+	if err != nil {
+		return fmt.Errorf("%w: invalid format: %s", cli.ExitCodeUsage, err)
+	}
+
+	for _, item := range items {
+		if err := tmpl.Execute(os.Stdout, item); err != nil {
+			return fmt.Errorf("%w: invalid format: %s", cli.ExitCodeUsage, err)
+		}
+
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -1,12 +1,17 @@
-export NVMDIR="${NVMDIR:-$HOME/.nvm}"
-export NVMBIN="$NVMDIR/bin"
+if [ -n "${NVMDIR:-}" ]; then
+    NVM_DATA_DIR="$NVMDIR"
+else
+    NVM_DATA_DIR="${XDG_DATA_HOME:-$HOME/.local/share}/go-nvm"
+fi
+
+export NVMBIN="$NVM_DATA_DIR/bin"
 
 case ":${PATH}:" in
-    *:"$NVMDIR":*)
+    *:"$NVM_DATA_DIR":*)
         ;;
     *:"$NVMBIN":*)
         ;;
     *)
-        export PATH="$NVMDIR:$NVMBIN:$PATH"
+        export PATH="$NVM_DATA_DIR:$NVMBIN:$PATH"
         ;;
 esac
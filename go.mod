@@ -2,4 +2,6 @@ module github.com/aronhoyer/go-nvm
 
 go 1.24.0
 
-require golang.org/x/net v0.37.0 // indirect
+require golang.org/x/term v0.30.0
+
+require golang.org/x/sys v0.31.0 // indirect
@@ -7,8 +7,11 @@ if ! command -v jq >/dev/null 2>&1; then
 	exit 69
 fi
 
-NVMDIR="${NVMDIR:-$HOME/.nvm}"
-NVMBIN="$NVMDIR/bin"
+if [ -n "${NVMDIR:-}" ]; then
+	NVM_DATA_DIR="$NVMDIR"
+else
+	NVM_DATA_DIR="${XDG_DATA_HOME:-$HOME/.local/share}/go-nvm"
+fi
 
 NVM_GET_UNSTABLE=1
 case "${1:-}" in
@@ -50,15 +53,16 @@ trap 'rm -rf $NVM_DOWNLOAD_TARGET' EXIT
 pushd "$NVM_DOWNLOAD_TARGET"
 curl -O -sLf "$NVM_ARTIFACT_URL"
 
-if [ -d "$NVMDIR" ]; then
-	rm -rf "$NVMDIR"
-fi
-
 mkdir "$NVM_RELEASE_NAME"
 tar -C "$NVM_RELEASE_NAME" -xzf "$NVM_ARTIFACT_NAME"
 
-mkdir -p "$NVMDIR"
-cp -f "$NVM_RELEASE_NAME"/* "$NVMDIR"
+# the data directory also holds the installed Node versions, aliases and the bin link, so only nvm itself is
+# replaced. each file is moved into place whole, so an nvm that's running keeps working.
+mkdir -p "$NVM_DATA_DIR"
+for f in nvm env; do
+	cp -f "$NVM_RELEASE_NAME/$f" "$NVM_DATA_DIR/.$f.tmp"
+	mv -f "$NVM_DATA_DIR/.$f.tmp" "$NVM_DATA_DIR/$f"
+done
 popd
 
-echo "nvm $NVM_TAG_NAME installed into $NVMDIR"
+echo "nvm $NVM_TAG_NAME installed into $NVM_DATA_DIR"
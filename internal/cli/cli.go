@@ -2,7 +2,8 @@ package cli
 
 import (
 	"os"
-	"path"
+
+	"github.com/aronhoyer/go-nvm/internal/paths"
 )
 
 type Args []string
@@ -16,32 +17,25 @@ func (s *Args) Get(n int) string {
 }
 
 type Cli struct {
-	nvmDir  string
+	paths   paths.Paths
 	Version string
 	RootCmd *Command
 }
 
-func New(nvmDir string, rootCmd *Command) *Cli {
+func New(p paths.Paths, rootCmd *Command) *Cli {
 	if rootCmd == nil {
 		panic("must provide a root command")
 	}
 
 	return &Cli{
-		nvmDir:  nvmDir,
+		paths:   p,
 		RootCmd: rootCmd,
 	}
 }
 
-func (c *Cli) RootPath() string {
-	return c.nvmDir
-}
-
-func (c *Cli) BinPath() string {
-	return path.Join(c.nvmDir, "bin")
-}
-
-func (c *Cli) VersionsDirPath() string {
-	return path.Join(c.nvmDir, "versions")
+// Paths returns the locations of the files nvm manages.
+func (c *Cli) Paths() paths.Paths {
+	return c.paths
 }
 
 func (c *Cli) Exec() {
@@ -3,6 +3,7 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"slices"
 	"strings"
@@ -19,6 +20,10 @@ type Command struct {
 	Commands    []*Command
 	Run         func(args Args, flags FlagSet) error
 
+	// Passthrough is the number of positional arguments after which flag parsing stops, and every remaining
+	// argument is passed to Run as is. Zero means flags are parsed throughout, until a "--" terminator.
+	Passthrough int
+
 	parent *Command
 }
 
@@ -26,14 +31,31 @@ func (cmd *Command) isRoot() bool {
 	return cmd.parent == nil
 }
 
+// globalFlags are accepted by every command, before the command name as well as after it. Every command shares
+// the same flags, so the value given last wins.
+var globalFlags []Flag
+
 func (cmd *Command) exec(args []string) {
 	if cmd.isRoot() {
 		if Version != nil {
 			cmd.Flags = append(cmd.Flags, NewBoolFlagP("version", "V", false, "Print version"))
 		}
 		// TODO: could add help command
+
+		globalFlags = []Flag{
+			NewStringFlagP("color", "", Color, "Colored output: auto, always or never"),
+			NewBoolFlagP("verbose", "v", false, "Log requests, cache use and installation steps to stderr"),
+			NewBoolFlagP("debug", "", false, "Log everything, including locking and linking, to stderr"),
+		}
+
+		var err error
+		if args, err = parseGlobalFlags(args); err != nil {
+			PrintError(err)
+			os.Exit(ExitCodeUsage.Code())
+		}
 	}
 
+	cmd.Flags = append(cmd.Flags, globalFlags...)
 	cmd.Flags = append(cmd.Flags, NewBoolFlagP("help", "h", false, "Print help"))
 
 	var arg string
@@ -50,8 +72,16 @@ func (cmd *Command) exec(args []string) {
 	}
 
 	parsedArgs, flags, err := cmd.parseArgs(args)
+	if err == nil {
+		err = SetColor(flags.GetString("color"))
+	}
+
+	if err == nil {
+		err = setupLogging(flags.GetBool("verbose"), flags.GetBool("debug"))
+	}
+
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "\x1b[1;31mError:\x1b[0m", err)
+		PrintError(err)
 
 		var exErr ExitCode
 		if errors.As(err, &exErr) {
@@ -75,13 +105,13 @@ func (cmd *Command) exec(args []string) {
 	}
 
 	if cmd.isRoot() && len(cmd.Commands) > 0 && len(args) == 0 {
-		fmt.Fprintf(os.Stderr, "\x1b[1;31mError:\x1b[0m a command is required\n\n")
+		PrintError("a command is required\n")
 		cmd.printUsage()
 		os.Exit(ExitCodeUsage.Code())
 	}
 
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "\x1b[1;31mError:\x1b[0m %s\n\n", err.Error())
+		PrintError(err.Error() + "\n")
 
 		var exErr ExitCode
 		if errors.As(err, &exErr) {
@@ -94,15 +124,19 @@ func (cmd *Command) exec(args []string) {
 	}
 
 	if cmd.Run != nil {
+		slog.Debug("running command", "command", cmd.Name, "args", []string(parsedArgs))
+
 		if err := cmd.Run(parsedArgs, flags); err != nil {
+			slog.Debug("command failed", "command", cmd.Name, "error", err)
+
 			var exErr ExitCode
 			if errors.As(err, &exErr) {
-				fmt.Fprintln(os.Stderr, err)
+				PrintError(err)
 				os.Exit(exErr.Code())
 			}
 		}
 	} else if len(parsedArgs) > 0 {
-		fmt.Printf("\x1b[1;31mError:\x1b[0m unknown command: %q\n", parsedArgs.Get(0))
+		PrintError(fmt.Sprintf("unknown command: %q", parsedArgs.Get(0)))
 		os.Exit(ExitCodeUsage.Code())
 	}
 }
@@ -111,26 +145,26 @@ func (cmd *Command) parseArgs(args []string) (Args, FlagSet, error) {
 	var remaining Args
 	flags := make(FlagSet)
 
-	for _, arg := range args {
-		if strings.HasPrefix(arg, "-") {
-			found := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 
-			for _, f := range cmd.Flags {
-				long, short := f.Name()
-				if arg == "--"+long || arg == "-"+short {
-					switch f.Value().Get().(type) {
-					case bool:
-						// BoolFlag.Set() calls PaseBool and ParseBool("true") should (tm) never error
-						f.Value().Set("true")
-					}
+		if arg == "--" || (cmd.Passthrough > 0 && len(remaining) >= cmd.Passthrough) {
+			// keep the terminator so Run can tell whether arguments were given before it
+			remaining = append(remaining, args[i:]...)
+			break
+		}
 
-					found = true
-				}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			n, err := setFlag(cmd.Flags, args[i:])
+			if err != nil {
+				return nil, nil, err
 			}
 
-			if !found {
+			if n == 0 {
 				return nil, nil, fmt.Errorf("%w: invalid flag: %s", ExitCodeUsage, arg)
 			}
+
+			i += n - 1
 		} else {
 			remaining = append(remaining, arg)
 		}
@@ -147,8 +181,66 @@ func (cmd *Command) parseArgs(args []string) (Args, FlagSet, error) {
 	return remaining, flags, nil
 }
 
+// parseGlobalFlags sets the global flags at the start of args, before the command name, and returns the rest.
+func parseGlobalFlags(args []string) ([]string, error) {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "-" && args[0] != "--" {
+		n, err := setFlag(globalFlags, args)
+		if err != nil {
+			return nil, err
+		}
+
+		if n == 0 {
+			break
+		}
+
+		args = args[n:]
+	}
+
+	return args, nil
+}
+
+// setFlag sets the flag in flags that args[0] names, taking its value from args[1] if needed. It returns the number
+// of arguments used, which is 0 if args[0] isn't one of the flags.
+func setFlag(flags []Flag, args []string) (int, error) {
+	name, value, hasValue := strings.Cut(args[0], "=")
+	n := 1
+
+	for _, f := range flags {
+		long, short := f.Name()
+		if name != "--"+long && (short == "" || name != "-"+short) {
+			continue
+		}
+
+		switch f.Value().Get().(type) {
+		case bool:
+			if !hasValue {
+				value = "true"
+			}
+		default:
+			if v, ok := f.Value().(*optionalStringValue); ok && !hasValue {
+				value = v.implicit
+			} else if !hasValue {
+				if len(args) < 2 {
+					return 0, fmt.Errorf("%w: flag %s requires a value", ExitCodeUsage, name)
+				}
+
+				value = args[1]
+				n = 2
+			}
+		}
+
+		if err := f.Value().Set(value); err != nil {
+			return 0, fmt.Errorf("%w: invalid value for flag %s: %s", ExitCodeUsage, name, value)
+		}
+
+		return n, nil
+	}
+
+	return 0, nil
+}
+
 func (cmd *Command) printUsage() {
-	fmt.Print("\x1b[1mUsage:\x1b[0m ")
+	fmt.Print(Stdout.Style("Usage:", Bold) + " ")
 
 	if cmd.Usage != "" {
 		fmt.Print(cmd.Usage)
@@ -166,7 +258,7 @@ func (cmd *Command) printUsage() {
 	fmt.Print("\n")
 
 	if len(cmd.Commands) > 0 {
-		fmt.Println("\n\x1b[1mCommands:\x1b[0m")
+		fmt.Println("\n" + Stdout.Style("Commands:", Bold))
 
 		maxLen := 0
 		names := make([]string, len(cmd.Commands))
@@ -191,7 +283,7 @@ func (cmd *Command) printUsage() {
 	}
 
 	if len(cmd.Flags) > 0 {
-		fmt.Println("\n\x1b[1mOptions:\x1b[0m")
+		fmt.Println("\n" + Stdout.Style("Options:", Bold))
 
 		maxLen := 0
 		names := make([]string, len(cmd.Flags))
@@ -203,6 +295,11 @@ func (cmd *Command) printUsage() {
 			}
 			nameParts = append(nameParts, "--"+long)
 			joined := strings.Join(nameParts, ", ")
+			if v, ok := flag.Value().(*optionalStringValue); ok {
+				joined += "[=<" + v.name + ">]"
+			} else if _, ok := flag.Value().Get().(bool); !ok {
+				joined += " <VALUE>"
+			}
 			names[i] = joined
 			if len(joined) > maxLen {
 				maxLen = len(joined)
@@ -34,6 +34,33 @@ func newBoolValue(b bool) *boolValue {
 	return (*boolValue)(&b)
 }
 
+type stringValue string
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) Get() any {
+	return string(*v)
+}
+
+func newStringValue(s string) *stringValue {
+	return (*stringValue)(&s)
+}
+
+// optionalStringValue is a string whose flag may be given without a value, e.g. --lts as well as --lts=iron. A
+// value must then be attached with "=", and the flag alone sets it to implicit. name is what the value is called
+// in the help, e.g. CODENAME.
+type optionalStringValue struct {
+	stringValue
+	implicit, name string
+}
+
 type Flag interface {
 	Name() (string, string)
 	Description() string
@@ -61,6 +88,33 @@ func NewBoolFlagP(long, short string, defVal bool, description string) Flag {
 	return &BoolFlag{long, short, description, newBoolValue(defVal)}
 }
 
+type StringFlag struct {
+	long, short, description string
+	value                    Value
+}
+
+func (f *StringFlag) Name() (string, string) {
+	return f.long, f.short
+}
+
+func (f *StringFlag) Description() string {
+	return f.description
+}
+
+func (f *StringFlag) Value() Value {
+	return f.value
+}
+
+func NewStringFlagP(long, short string, defVal string, description string) Flag {
+	return &StringFlag{long, short, description, newStringValue(defVal)}
+}
+
+// NewOptionalStringFlagP returns a string flag that takes its value only when attached with "=", and is set to
+// implicit when given alone. The help shows the flag as --long[=<valueName>].
+func NewOptionalStringFlagP(long, short string, defVal, implicit, valueName string, description string) Flag {
+	return &StringFlag{long, short, description, &optionalStringValue{stringValue(defVal), implicit, valueName}}
+}
+
 type FlagSet map[string]Flag
 
 func (s FlagSet) GetBool(long string) bool {
@@ -76,3 +130,17 @@ func (s FlagSet) GetBool(long string) bool {
 
 	return b
 }
+
+func (s FlagSet) GetString(long string) string {
+	f, ok := s[long]
+	if !ok {
+		return ""
+	}
+
+	str, ok := f.Value().Get().(string)
+	if !ok {
+		return ""
+	}
+
+	return str
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+func init() {
+	// the node and platform packages log to the default logger, which prints to stderr unless it's replaced
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+}
+
+// setupLogging points the default logger at stderr if --verbose or --debug was given, or NVM_LOG is set. Verbose
+// logging is at the info level, debug logging at the debug level. NVM_LOG selects the format, "text" or "json".
+func setupLogging(verbose, debug bool) error {
+	format := os.Getenv("NVM_LOG")
+
+	var opts slog.HandlerOptions
+	switch {
+	case debug:
+		opts.Level = slog.LevelDebug
+	case verbose, format != "":
+		opts.Level = slog.LevelInfo
+	default:
+		return nil
+	}
+
+	var h slog.Handler
+	switch format {
+	case "", "text":
+		// the time is noise when reading along with a command as it runs
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		}
+
+		h = slog.NewTextHandler(os.Stderr, &opts)
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, &opts)
+	default:
+		return fmt.Errorf("%w: invalid NVM_LOG %q, expected text or json", ExitCodeConfig, format)
+	}
+
+	slog.SetDefault(slog.New(h))
+	return nil
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aronhoyer/go-nvm/internal/platform"
+)
+
+// Color is the default of the --color flag of every command, one of "auto", "always" or "never".
+var Color = "auto"
+
+// Style is an SGR attribute of styled text.
+type Style string
+
+const (
+	Bold   Style = "1"
+	Red    Style = "31"
+	Green  Style = "32"
+	Yellow Style = "33"
+)
+
+// Styler styles text written to a stream, if colors are enabled for it.
+type Styler struct {
+	f       *os.File
+	enabled bool
+}
+
+var (
+	Stdout = &Styler{f: os.Stdout}
+	Stderr = &Styler{f: os.Stderr}
+)
+
+func init() {
+	SetColor(Color)
+}
+
+// SetColor enables or disables colors on stdout and stderr. mode is "always", "never", or "auto", which enables
+// them for terminals unless NO_COLOR is set. FORCE_COLOR enables them in auto mode even if they aren't terminals.
+func SetColor(mode string) error {
+	for _, s := range []*Styler{Stdout, Stderr} {
+		switch mode {
+		case "always":
+			s.enabled = true
+		case "never":
+			s.enabled = false
+		case "auto":
+			s.enabled = autoColor(s.f)
+		default:
+			return fmt.Errorf("%w: invalid color mode %q, expected auto, always or never", ExitCodeUsage, mode)
+		}
+	}
+
+	return nil
+}
+
+func autoColor(f *os.File) bool {
+	// see https://no-color.org and https://force-color.org
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	if v := os.Getenv("FORCE_COLOR"); v != "" && v != "0" && v != "false" {
+		return true
+	}
+
+	return platform.IsTerminal(f) && os.Getenv("TERM") != "dumb"
+}
+
+// Enabled reports whether text written to the stream is styled.
+func (s *Styler) Enabled() bool {
+	return s.enabled
+}
+
+// Style returns text with styles applied, or text as is if colors are disabled.
+func (s *Styler) Style(text string, styles ...Style) string {
+	if !s.enabled || len(styles) == 0 {
+		return text
+	}
+
+	codes := make([]string, len(styles))
+	for i, st := range styles {
+		codes[i] = string(st)
+	}
+
+	return "\x1b[" + strings.Join(codes, ";") + "m" + text + "\x1b[0m"
+}
+
+// PrintError prints err to stderr, prefixed with "Error:".
+func PrintError(err any) {
+	fmt.Fprintln(os.Stderr, Stderr.Style("Error:", Bold, Red), err)
+}
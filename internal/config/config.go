@@ -0,0 +1,385 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownKey   = errors.New("unknown setting")
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+type Config struct {
+	// Mirror is the base URL of the Node distribution to install from.
+	Mirror string
+	// Arch is the architecture of the releases to install, e.g. "x64" or "arm64". Empty means the host's.
+	Arch string
+	// VersionFiles are the names of the files that pin a project's Node version, in order of precedence.
+	VersionFiles []string
+	// Color is one of "auto", "always" or "never".
+	Color string
+	// AutoInstall installs versions that are missing when they're used.
+	AutoInstall bool
+	// CacheTTL is how long a cached remote index is used before it's fetched again.
+	CacheTTL time.Duration
+	// Verify checks downloaded releases against the checksums published with them.
+	Verify bool
+	// Corepack enables the corepack shims of every installed version.
+	Corepack bool
+}
+
+// Default returns the configuration used when nothing is configured.
+func Default() Config {
+	return Config{
+		Mirror:       "https://nodejs.org/dist",
+		VersionFiles: []string{".nvmrc", ".node-version"},
+		Color:        "auto",
+		CacheTTL:     time.Hour,
+		Verify:       true,
+	}
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindList
+	kindDuration
+)
+
+type setting struct {
+	key  string
+	kind kind
+	set  func(c *Config, v string) error
+	get  func(c *Config) string
+}
+
+// env is the environment variable overriding the setting, e.g. NVM_CACHE_TTL for cache-ttl.
+func (s setting) env() string {
+	return "NVM_" + strings.ToUpper(strings.ReplaceAll(s.key, "-", "_"))
+}
+
+var settings = []setting{
+	{
+		key:  "mirror",
+		kind: kindString,
+		set: func(c *Config, v string) error {
+			u, err := url.Parse(v)
+			if err != nil || u.Scheme == "" || u.Host == "" && u.Scheme != "file" {
+				return fmt.Errorf("%w: %q is not an absolute URL", ErrInvalidValue, v)
+			}
+
+			c.Mirror = strings.TrimSuffix(v, "/")
+			return nil
+		},
+		get: func(c *Config) string { return c.Mirror },
+	},
+	{
+		key:  "arch",
+		kind: kindString,
+		set: func(c *Config, v string) error {
+			if v != "" && !slices.Contains([]string{"x64", "x86", "arm64", "armv7l", "ppc64le", "s390x"}, v) {
+				return fmt.Errorf("%w: unsupported architecture %q", ErrInvalidValue, v)
+			}
+
+			c.Arch = v
+			return nil
+		},
+		get: func(c *Config) string { return c.Arch },
+	},
+	{
+		key:  "version-files",
+		kind: kindList,
+		set: func(c *Config, v string) error {
+			var files []string
+			for _, f := range strings.Split(v, ",") {
+				if f = strings.TrimSpace(f); f == "" {
+					continue
+				}
+
+				if strings.ContainsAny(f, `/\`) {
+					return fmt.Errorf("%w: %q is not a file name", ErrInvalidValue, f)
+				}
+
+				files = append(files, f)
+			}
+
+			if len(files) == 0 {
+				return fmt.Errorf("%w: at least one version file is required", ErrInvalidValue)
+			}
+
+			c.VersionFiles = files
+			return nil
+		},
+		get: func(c *Config) string { return strings.Join(c.VersionFiles, ",") },
+	},
+	{
+		key:  "color",
+		kind: kindString,
+		set: func(c *Config, v string) error {
+			if !slices.Contains([]string{"auto", "always", "never"}, v) {
+				return fmt.Errorf("%w: %q, expected auto, always or never", ErrInvalidValue, v)
+			}
+
+			c.Color = v
+			return nil
+		},
+		get: func(c *Config) string { return c.Color },
+	},
+	{
+		key:  "auto-install",
+		kind: kindBool,
+		set:  boolSetter(func(c *Config) *bool { return &c.AutoInstall }),
+		get:  func(c *Config) string { return strconv.FormatBool(c.AutoInstall) },
+	},
+	{
+		key:  "cache-ttl",
+		kind: kindDuration,
+		set: func(c *Config, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return fmt.Errorf("%w: %q is not a duration", ErrInvalidValue, v)
+			}
+
+			c.CacheTTL = d
+			return nil
+		},
+		get: func(c *Config) string { return c.CacheTTL.String() },
+	},
+	{
+		key:  "verify",
+		kind: kindBool,
+		set:  boolSetter(func(c *Config) *bool { return &c.Verify }),
+		get:  func(c *Config) string { return strconv.FormatBool(c.Verify) },
+	},
+	{
+		key:  "corepack",
+		kind: kindBool,
+		set:  boolSetter(func(c *Config) *bool { return &c.Corepack }),
+		get:  func(c *Config) string { return strconv.FormatBool(c.Corepack) },
+	},
+}
+
+func boolSetter(field func(c *Config) *bool) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%w: %q, expected true or false", ErrInvalidValue, v)
+		}
+
+		*field(c) = b
+		return nil
+	}
+}
+
+func lookup(key string) (setting, error) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, nil
+		}
+	}
+
+	return setting{}, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+}
+
+// Keys returns the names of all settings.
+func Keys() []string {
+	keys := make([]string, len(settings))
+	for i, s := range settings {
+		keys[i] = s.key
+	}
+
+	return keys
+}
+
+// Get returns the value of the setting key in its text form, the form accepted by Set. Lists are comma separated.
+func (c *Config) Get(key string) (string, error) {
+	s, err := lookup(key)
+	if err != nil {
+		return "", err
+	}
+
+	return s.get(c), nil
+}
+
+// Set parses value, in its text form, into the setting key.
+func (c *Config) Set(key, value string) error {
+	s, err := lookup(key)
+	if err != nil {
+		return err
+	}
+
+	if err := s.set(c, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Load reads the config file at p on top of the defaults, and then applies the NVM_* environment variables, which
+// take precedence over the file. A missing file is not an error. Invalid settings are reported in the error, but
+// don't stop the valid ones from being applied, so the returned config is usable either way.
+func Load(p string) (Config, error) {
+	c := Default()
+
+	b, err := os.ReadFile(p)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return c, err
+	}
+
+	var errs []error
+
+	values, err := parseTOML(string(b))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", p, err))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if err := c.Set(key, values[key]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p, err))
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.env()); ok {
+			if err := s.set(&c, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env(), err))
+			}
+		}
+	}
+
+	return c, errors.Join(errs...)
+}
+
+// Overridden returns the environment variable that overrides the setting key, or "" if it isn't overridden.
+func Overridden(key string) string {
+	s, err := lookup(key)
+	if err != nil {
+		return ""
+	}
+
+	if _, ok := os.LookupEnv(s.env()); ok {
+		return s.env()
+	}
+
+	return ""
+}
+
+// WriteValue sets key to value in the config file at p, creating the file if needed. value is validated first.
+// The rest of the file, including comments, is left as is.
+func WriteValue(p, key, value string) error {
+	s, err := lookup(key)
+	if err != nil {
+		return err
+	}
+
+	c := Default()
+	if err := s.set(&c, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return rewrite(p, key, key+" = "+encode(s, s.get(&c)))
+}
+
+// RemoveValue removes key from the config file at p, reverting it to its default.
+func RemoveValue(p, key string) error {
+	if _, err := lookup(key); err != nil {
+		return err
+	}
+
+	return rewrite(p, key, "")
+}
+
+// rewrite replaces the top-level line that sets key with line, or appends line if there is none. An empty line
+// removes the key.
+func rewrite(p, key, line string) error {
+	b, err := os.ReadFile(p)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// make sure the file parses, so a broken file isn't made worse
+	if _, err := parseTOML(string(b)); err != nil {
+		return fmt.Errorf("%s: %w", p, err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(b) == 0 {
+		lines = nil
+	}
+
+	// top-level keys must come before the first table
+	end := len(lines)
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "[") {
+			end = i
+			break
+		}
+	}
+
+	replaced := false
+	for i := 0; i < end; i++ {
+		k, _, ok := strings.Cut(lines[i], "=")
+		if !ok || strings.Trim(strings.TrimSpace(k), `"'`) != key {
+			continue
+		}
+
+		// an array may continue on the following lines
+		j := i + 1
+		for depth := strings.Count(lines[i], "[") - strings.Count(lines[i], "]"); depth > 0 && j < end; j++ {
+			depth += strings.Count(lines[j], "[") - strings.Count(lines[j], "]")
+		}
+
+		if line == "" {
+			lines = slices.Delete(lines, i, j)
+		} else {
+			lines = slices.Replace(lines, i, j, line)
+		}
+
+		replaced = true
+		break
+	}
+
+	if !replaced && line != "" {
+		lines = slices.Insert(lines, end, line)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	out := strings.Join(lines, "\n")
+	if out != "" {
+		out += "\n"
+	}
+
+	return os.WriteFile(p, []byte(out), 0o644)
+}
+
+// encode returns v, the text form of a value of the setting s, as a TOML value.
+func encode(s setting, v string) string {
+	switch s.kind {
+	case kindBool:
+		return v
+	case kindList:
+		items := strings.Split(v, ",")
+		for i, item := range items {
+			items[i] = quoteTOML(item)
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return quoteTOML(v)
+	}
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfig = `# go-nvm settings
+mirror = "https://example.com/dist" # a mirror
+
+# pinned files
+version-files = [
+  ".nvmrc",
+  ".node-version",
+]
+verify = false
+
+[extra]
+# kept as is
+mirror = "not this one"
+`
+
+func TestWriteValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		key, value string
+		want       string
+	}{
+		{
+			name:  "replace",
+			key:   "mirror",
+			value: "https://mirror.test/node/",
+			want: `# go-nvm settings
+mirror = "https://mirror.test/node"
+
+# pinned files
+version-files = [
+  ".nvmrc",
+  ".node-version",
+]
+verify = false
+
+[extra]
+# kept as is
+mirror = "not this one"
+`,
+		},
+		{
+			name:  "replace multi-line array",
+			key:   "version-files",
+			value: ".tool-versions, .nvmrc",
+			want: `# go-nvm settings
+mirror = "https://example.com/dist" # a mirror
+
+# pinned files
+version-files = [".tool-versions", ".nvmrc"]
+verify = false
+
+[extra]
+# kept as is
+mirror = "not this one"
+`,
+		},
+		{
+			name:  "add before first table",
+			key:   "auto-install",
+			value: "true",
+			want: `# go-nvm settings
+mirror = "https://example.com/dist" # a mirror
+
+# pinned files
+version-files = [
+  ".nvmrc",
+  ".node-version",
+]
+verify = false
+
+auto-install = true
+[extra]
+# kept as is
+mirror = "not this one"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(p, []byte(testConfig), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := WriteValue(p, tt.key, tt.value); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.want {
+				t.Errorf("file is\n%s\nwant\n%s", b, tt.want)
+			}
+
+			if _, err := parseTOML(string(b)); err != nil {
+				t.Errorf("written file doesn't parse: %s", err)
+			}
+		})
+	}
+}
+
+func TestWriteValueNewFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "go-nvm", "config.toml")
+
+	if err := WriteValue(p, "color", "never"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteValue(p, "cache-ttl", "30m"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "color = \"never\"\ncache-ttl = \"30m0s\"\n"; string(b) != want {
+		t.Errorf("file is %q, want %q", b, want)
+	}
+}
+
+func TestWriteValueInvalid(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(p, []byte(testConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteValue(p, "colour", "never"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key: error = %v", err)
+	}
+
+	if err := WriteValue(p, "verify", "maybe"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("invalid value: error = %v", err)
+	}
+
+	if b, _ := os.ReadFile(p); string(b) != testConfig {
+		t.Errorf("file was changed to\n%s", b)
+	}
+}
+
+func TestWriteValueBrokenFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.toml")
+	broken := "mirror = \"unterminated\n"
+	if err := os.WriteFile(p, []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteValue(p, "verify", "true"); !errors.Is(err, ErrSyntax) {
+		t.Errorf("error = %v, want a syntax error", err)
+	}
+
+	if b, _ := os.ReadFile(p); string(b) != broken {
+		t.Errorf("broken file was changed to %q", b)
+	}
+}
+
+func TestRemoveValue(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{
+			name: "single line",
+			key:  "mirror",
+			want: `# go-nvm settings
+
+# pinned files
+version-files = [
+  ".nvmrc",
+  ".node-version",
+]
+verify = false
+
+[extra]
+# kept as is
+mirror = "not this one"
+`,
+		},
+		{
+			name: "multi-line array",
+			key:  "version-files",
+			want: `# go-nvm settings
+mirror = "https://example.com/dist" # a mirror
+
+# pinned files
+verify = false
+
+[extra]
+# kept as is
+mirror = "not this one"
+`,
+		},
+		{
+			name: "not set",
+			key:  "color",
+			want: testConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(p, []byte(testConfig), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := RemoveValue(p, tt.key); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.want {
+				t.Errorf("file is\n%s\nwant\n%s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, k := range []string{"NVM_MIRROR", "NVM_VERIFY", "NVM_VERSION_FILES", "NVM_CACHE_TTL"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+
+	p := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(p, []byte("mirror = \"https://example.com/dist\"\nverify = false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("NVM_VERIFY", "true")
+
+	c, err := Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Mirror != "https://example.com/dist" {
+		t.Errorf("Mirror = %s, want the file's", c.Mirror)
+	}
+
+	if !c.Verify {
+		t.Error("Verify = false, want the environment to take precedence")
+	}
+
+	if def := Default(); c.CacheTTL != def.CacheTTL {
+		t.Errorf("CacheTTL = %s, want the default", c.CacheTTL)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.toml")); err != nil {
+		t.Errorf("missing file: %s", err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, k := range []string{"NVM_MIRROR", "NVM_VERIFY", "NVM_VERSION_FILES", "NVM_CACHE_TTL"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+
+	p := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(p, []byte("mirror = \"https://example.com/dist\"\narch = \"amd64\"\ncolour = \"never\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("NVM_VERIFY", "yes")
+
+	c, err := Load(p)
+	if !errors.Is(err, ErrInvalidValue) || !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("error = %v, want every invalid setting reported", err)
+	}
+
+	// the valid settings still apply, the invalid ones keep their defaults
+	def := Default()
+	if c.Mirror != "https://example.com/dist" || c.Arch != def.Arch || c.Verify != def.Verify {
+		t.Errorf("Load() = %+v, want the file's mirror and the default arch and verify", c)
+	}
+
+	if err := os.WriteFile(p, []byte("mirror = \"unterminated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if c, err := Load(p); !errors.Is(err, ErrSyntax) || c.Mirror != def.Mirror {
+		t.Errorf("broken file: Load() = %+v, %v, want the defaults and a syntax error", c, err)
+	}
+}
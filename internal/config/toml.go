@@ -0,0 +1,269 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrSyntax = errors.New("syntax error")
+
+// parseTOML parses the subset of TOML that config files need: comments, [table] headers, and key/value pairs with
+// string, boolean, integer and array values. Keys inside a table are prefixed with the table name and a dot.
+// Values are returned in their text form, see [setting.set], arrays joined with commas.
+func parseTOML(s string) (map[string]string, error) {
+	p := &tomlParser{s: s, line: 1}
+	values := make(map[string]string)
+
+	var table string
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return values, nil
+		}
+
+		if p.peek() == '[' {
+			p.pos++
+			p.skipSpace(false)
+
+			name, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+
+			p.skipSpace(false)
+			if !p.consume(']') {
+				return nil, p.errorf("expected ] after table name")
+			}
+
+			table = name + "."
+		} else {
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+
+			p.skipSpace(false)
+			if !p.consume('=') {
+				return nil, p.errorf("expected = after key %s", key)
+			}
+			p.skipSpace(false)
+
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+
+			key = table + key
+			if _, ok := values[key]; ok {
+				return nil, p.errorf("duplicate key %s", key)
+			}
+			values[key] = v
+		}
+
+		p.skipSpace(false)
+		if !p.eof() && p.peek() != '\n' {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+}
+
+type tomlParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.s[p.pos]
+}
+
+func (p *tomlParser) consume(c byte) bool {
+	if !p.eof() && p.peek() == c {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *tomlParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrSyntax, p.line, fmt.Sprintf(format, a...))
+}
+
+// skipSpace skips blanks and comments, and newlines too if newlines is set.
+func (p *tomlParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+			p.line++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// key parses a possibly dotted key of bare and quoted parts.
+func (p *tomlParser) key() (string, error) {
+	var parts []string
+
+	for {
+		p.skipSpace(false)
+		if p.eof() {
+			return "", p.errorf("expected key")
+		}
+
+		var part string
+		if c := p.peek(); c == '"' || c == '\'' {
+			s, err := p.str()
+			if err != nil {
+				return "", err
+			}
+			part = s
+		} else {
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+
+			if p.pos == start {
+				return "", p.errorf("expected key")
+			}
+			part = p.s[start:p.pos]
+		}
+
+		parts = append(parts, part)
+
+		p.skipSpace(false)
+		if !p.consume('.') {
+			return strings.Join(parts, "."), nil
+		}
+	}
+}
+
+func (p *tomlParser) value() (string, error) {
+	if p.eof() {
+		return "", p.errorf("expected value")
+	}
+
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		p.pos++
+
+		var items []string
+		for {
+			p.skipSpace(true)
+			if p.consume(']') {
+				return strings.Join(items, ","), nil
+			}
+
+			v, err := p.value()
+			if err != nil {
+				return "", err
+			}
+			items = append(items, v)
+
+			p.skipSpace(true)
+			if !p.consume(',') {
+				p.skipSpace(true)
+				if !p.consume(']') {
+					return "", p.errorf("expected , or ] in array")
+				}
+
+				return strings.Join(items, ","), nil
+			}
+		}
+	default:
+		start := p.pos
+		for !p.eof() && isBareKeyChar(p.peek()) || !p.eof() && (p.peek() == '+' || p.peek() == '.') {
+			p.pos++
+		}
+
+		v := p.s[start:p.pos]
+		if v == "true" || v == "false" {
+			return v, nil
+		}
+
+		if n, err := strconv.ParseInt(strings.ReplaceAll(v, "_", ""), 0, 64); err == nil {
+			return strconv.FormatInt(n, 10), nil
+		}
+
+		return "", p.errorf("invalid value %q", v)
+	}
+}
+
+// str parses a basic ("...") or literal ('...') single-line string.
+func (p *tomlParser) str() (string, error) {
+	quote := p.peek()
+	start := p.pos
+	p.pos++
+
+	for !p.eof() && p.peek() != '\n' {
+		c := p.peek()
+		p.pos++
+
+		if c == '\\' && quote == '"' {
+			p.pos++
+			continue
+		}
+
+		if c == quote {
+			raw := p.s[start:p.pos]
+			if quote == '\'' {
+				return raw[1 : len(raw)-1], nil
+			}
+
+			s, err := strconv.Unquote(raw)
+			if err != nil {
+				return "", p.errorf("invalid string %s", raw)
+			}
+
+			return s, nil
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// quoteTOML encodes s as a TOML basic string.
+func quoteTOML(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{"empty", "", map[string]string{}},
+		{"comments only", "# a comment\n\n  # another\n", map[string]string{}},
+		{"basic string", `mirror = "https://example.com/dist"`, map[string]string{"mirror": "https://example.com/dist"}},
+		{"literal string", `mirror = 'C:\dist'`, map[string]string{"mirror": `C:\dist`}},
+		{"escapes", `s = "a\"b\\c\td\u00e9"`, map[string]string{"s": "a\"b\\c\td\u00e9"}},
+		{"hash in string", `s = "a#b" # comment`, map[string]string{"s": "a#b"}},
+		{"booleans", "a = true\nb = false", map[string]string{"a": "true", "b": "false"}},
+		{"integers", "a = 42\nb = +1_000\nc = 0x10", map[string]string{"a": "42", "b": "1000", "c": "16"}},
+		{"quoted key", `"cache-ttl" = "1h"`, map[string]string{"cache-ttl": "1h"}},
+		{"dotted key", `a.b = 1`, map[string]string{"a.b": "1"}},
+		{"crlf", "a = 1\r\nb = 2\r\n", map[string]string{"a": "1", "b": "2"}},
+		{"array", `files = [".nvmrc", ".node-version"]`, map[string]string{"files": ".nvmrc,.node-version"}},
+		{"empty array", `files = []`, map[string]string{"files": ""}},
+		{"trailing comma", `files = ["a", "b",]`, map[string]string{"files": "a,b"}},
+		{
+			"multi-line array",
+			"files = [\n  \".nvmrc\", # first\n  \".node-version\",\n]\nverify = true\n",
+			map[string]string{"files": ".nvmrc,.node-version", "verify": "true"},
+		},
+		{
+			"tables",
+			"verify = true\n[npm]\nregistry = \"r\"\n[ other ]\nx = 1\n",
+			map[string]string{"verify": "true", "npm.registry": "r", "other.x": "1"},
+		},
+		{"same key in different tables", "x = 1\n[t]\nx = 2", map[string]string{"x": "1", "t.x": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.in)
+			if err != nil {
+				t.Fatalf("parseTOML(%q): %s", tt.in, err)
+			}
+
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseTOML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"duplicate key", "a = 1\na = 2"},
+		{"duplicate key in table", "[t]\na = 1\na = 2"},
+		{"missing =", "a 1"},
+		{"missing value", "a ="},
+		{"invalid value", "a = yes"},
+		{"unterminated string", `a = "abc`},
+		{"string across lines", "a = \"abc\ndef\""},
+		{"invalid escape", `a = "\q"`},
+		{"unterminated array", `a = [1, 2`},
+		{"missing comma", `a = [1 2]`},
+		{"unterminated table", "[t\na = 1"},
+		{"trailing garbage", `a = "b" c`},
+		{"missing key", `= 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := parseTOML(tt.in); !errors.Is(err, ErrSyntax) {
+				t.Errorf("parseTOML(%q) = %q, %v, want a syntax error", tt.in, got, err)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrorLine(t *testing.T) {
+	_, err := parseTOML("a = 1\n\n# comment\nb = ?\n")
+	if err == nil || err.Error() != "syntax error: line 4: invalid value \"\"" {
+		t.Errorf("error = %v, want one on line 4", err)
+	}
+}
+
+func TestQuoteTOML(t *testing.T) {
+	for _, s := range []string{"", "plain", `quote " and \ backslash`, "tab\tnewline\n", "bell\x07", "é"} {
+		got, err := parseTOML("s = " + quoteTOML(s))
+		if err != nil {
+			t.Errorf("quoteTOML(%q) = %s, which doesn't parse: %s", s, quoteTOML(s), err)
+			continue
+		}
+
+		if got["s"] != s {
+			t.Errorf("quoteTOML(%q) = %s, which parses as %q", s, quoteTOML(s), got["s"])
+		}
+	}
+}
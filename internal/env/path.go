@@ -6,10 +6,13 @@ import (
 	"io/fs"
 	"os"
 	"path"
+
+	"github.com/aronhoyer/go-nvm/internal/paths"
+	"github.com/aronhoyer/go-nvm/internal/platform"
 )
 
-func SetNodeVersion(v string) error {
-	versionDir := path.Join(os.Getenv("NVMDIR"), "versions", v)
+func SetNodeVersion(p paths.Paths, v string) error {
+	versionDir := p.Version(v)
 
 	if _, err := os.Stat(versionDir); err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
@@ -19,15 +22,7 @@ func SetNodeVersion(v string) error {
 		return err
 	}
 
-	if _, err := os.Stat(os.Getenv("NVMBIN")); err != nil && !errors.Is(err, fs.ErrNotExist) {
-		return err
-	} else {
-		if err := os.RemoveAll(os.Getenv("NVMBIN")); err != nil {
-			panic(err)
-		}
-	}
-
-	if err := os.Symlink(path.Join(versionDir, "bin"), os.Getenv("NVMBIN")); err != nil {
+	if err := platform.SymlinkForce(path.Join(versionDir, "bin"), p.Bin()); err != nil {
 		return err
 	}
 
@@ -0,0 +1,124 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+var ErrInvalidAlias = errors.New("invalid alias name")
+
+// reservedAliases can't be used as alias names, because they'd be shadowed by the version specs of the same name.
+var reservedAliases = []string{"latest", "node", "lts", SystemVersion}
+
+type Alias struct {
+	Name, Target string
+}
+
+// aliasPath validates the alias name and returns the path of its file in aliasDir. Names are case-insensitive, like
+// version specs, and stored lowercase. A file stored with another case before that was the case is still found.
+func aliasPath(aliasDir, name string) (string, error) {
+	name = strings.ToLower(name)
+
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\ `) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAlias, name)
+	}
+
+	for _, r := range reservedAliases {
+		if name == r {
+			return "", fmt.Errorf("%w: %q is reserved", ErrInvalidAlias, name)
+		}
+	}
+
+	p := path.Join(aliasDir, name)
+	if _, err := os.Lstat(p); errors.Is(err, fs.ErrNotExist) {
+		entries, _ := os.ReadDir(aliasDir)
+		for _, e := range entries {
+			if strings.EqualFold(e.Name(), name) {
+				return path.Join(aliasDir, e.Name()), nil
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// ReadAlias returns the version spec stored under the alias name in aliasDir.
+func ReadAlias(aliasDir, name string) (string, error) {
+	p, err := aliasPath(aliasDir, name)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// WriteAlias stores target, a version spec, under the alias name in aliasDir.
+func WriteAlias(aliasDir, name, target string) error {
+	p, err := aliasPath(aliasDir, name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(aliasDir, 0o755); err != nil {
+		return err
+	}
+
+	lower := path.Join(aliasDir, strings.ToLower(name))
+	if err := os.WriteFile(lower, []byte(strings.TrimSpace(target)+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	// replace a file stored with another case, rather than keeping both
+	if p != lower {
+		return os.Remove(p)
+	}
+
+	return nil
+}
+
+// RemoveAlias deletes the alias name from aliasDir.
+func RemoveAlias(aliasDir, name string) error {
+	p, err := aliasPath(aliasDir, name)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(p)
+}
+
+// ListAliases returns every alias in aliasDir, sorted by name.
+func ListAliases(aliasDir string) ([]Alias, error) {
+	entries, err := os.ReadDir(aliasDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var aliases []Alias
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		target, err := ReadAlias(aliasDir, e.Name())
+		if err != nil {
+			continue
+		}
+
+		aliases = append(aliases, Alias{strings.ToLower(e.Name()), target})
+	}
+
+	return aliases, nil
+}
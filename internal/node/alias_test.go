@@ -0,0 +1,68 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAliasNamesAreCaseInsensitive(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := WriteAlias(dir, "Work", "18"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"work", "Work", "WORK"} {
+		if target, err := ReadAlias(dir, name); err != nil || target != "18" {
+			t.Errorf("ReadAlias(%q) = %q, %v, want 18", name, target, err)
+		}
+	}
+
+	aliases, err := ListAliases(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(aliases) != 1 || aliases[0] != (Alias{"work", "18"}) {
+		t.Errorf("ListAliases() = %v, want work -> 18", aliases)
+	}
+
+	if err := WriteAlias(dir, "LTS", "18"); err == nil {
+		t.Error("WriteAlias(\"LTS\") succeeded, want the reserved name rejected")
+	}
+}
+
+func TestAliasWithLegacyCase(t *testing.T) {
+	dir := t.TempDir()
+
+	// aliases used to be stored with the case they were given
+	if err := os.WriteFile(filepath.Join(dir, "Work"), []byte("18\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if target, err := ReadAlias(dir, "work"); err != nil || target != "18" {
+		t.Errorf("ReadAlias() = %q, %v, want 18", target, err)
+	}
+
+	if err := WriteAlias(dir, "work", "20"); err != nil {
+		t.Fatal(err)
+	}
+
+	aliases, err := ListAliases(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(aliases) != 1 || aliases[0] != (Alias{"work", "20"}) {
+		t.Errorf("ListAliases() = %v, want only work -> 20", aliases)
+	}
+
+	if err := RemoveAlias(dir, "WORK"); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("%d files left after removing the alias", len(entries))
+	}
+}
@@ -2,52 +2,175 @@ package node
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path"
 	"strings"
+	"time"
 )
 
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 type Artifact struct {
 	Name, Slug, Ext string
 }
 
 func DownloadArtifact(v, s string) (Artifact, error) {
-	u, err := url.JoinPath("https://nodejs.org/dist", v, s)
+	u, err := url.JoinPath(DistURL, v, s)
 	if err != nil {
 		return Artifact{}, err
 	}
 
-	r, err := http.Get(u)
+	// the download as a whole isn't timed out, a large release on a slow connection can take a while, but one that
+	// stops receiving data is given up on
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	stalled := time.AfterFunc(downloadStallTimeout, func() {
+		cancel(fmt.Errorf("no data received for %s", downloadStallTimeout))
+	})
+	defer stalled.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return Artifact{}, err
 	}
 
+	slog.Info("downloading", "url", u)
+	r, err := downloadClient.Do(req)
+	if err != nil {
+		return Artifact{}, downloadErr(ctx, err)
+	}
+
+	slog.Info("response", "url", u, "status", r.Status, "size", r.ContentLength)
+
 	if r.StatusCode >= 400 {
-		return Artifact{}, fmt.Errorf("failed to download artifact %s: request failed with status %s", s, r.Status)
+		return Artifact{}, fmt.Errorf("request to %s failed with status %s", u, r.Status)
 	}
 
 	defer r.Body.Close()
 
-	f, err := os.Create(path.Join(os.TempDir(), s))
+	// downloads are written to the cache directory, or the system's temporary directory if caching is disabled.
+	// They're only needed until they're extracted, so the caller removes them.
+	dir := CacheDir
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return Artifact{}, err
+		}
+	}
+
+	// the slug is kept at the end of the name, since extraction goes by the file extension
+	f, err := os.CreateTemp(dir, "*-"+s)
 	if err != nil {
 		return Artifact{}, err
 	}
 
 	defer f.Close()
 
-	if _, err := io.Copy(f, r.Body); err != nil {
-		return Artifact{}, err
+	n, err := io.Copy(f, stallReader{r.Body, stalled})
+	if err != nil {
+		os.Remove(f.Name())
+		return Artifact{}, downloadErr(ctx, err)
 	}
 
+	slog.Debug("downloaded", "url", u, "path", f.Name(), "bytes", n)
+
 	return Artifact{f.Name(), s, path.Ext(f.Name())}, nil
 }
 
+// downloadStallTimeout is how long a download may go without receiving any data before it's given up on.
+var downloadStallTimeout = 30 * time.Second
+
+// downloadClient is used for release artifacts. Unlike httpClient, it has no overall timeout, see DownloadArtifact.
+var downloadClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	},
+}
+
+// stallReader restarts the stall timer of a download whenever data is received.
+type stallReader struct {
+	r     io.Reader
+	timer *time.Timer
+}
+
+func (s stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		s.timer.Reset(downloadStallTimeout)
+	}
+
+	return n, err
+}
+
+// downloadErr returns why a download was cancelled, if it was, rather than the bare context error.
+func downloadErr(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+
+	return err
+}
+
+// VerifyArtifact checks the SHA-256 checksum of a, an artifact of version v, against the SHASUMS256.txt file
+// published with the release.
+func VerifyArtifact(v string, a Artifact) error {
+	u, err := url.JoinPath(DistURL, v, "SHASUMS256.txt")
+	if err != nil {
+		return err
+	}
+
+	sums, err := fetch(u)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve checksums: %w", err)
+	}
+
+	var want string
+	for _, line := range strings.Split(string(sums), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[1] == a.Slug {
+			want = strings.ToLower(fields[0])
+			break
+		}
+	}
+
+	if want == "" {
+		return fmt.Errorf("%w: no checksum published for %s", ErrChecksumMismatch, a.Slug)
+	}
+
+	f, err := os.Open(a.Name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: %s has checksum %s, expected %s", ErrChecksumMismatch, a.Slug, got, want)
+	}
+
+	slog.Info("verified checksum", "artifact", a.Slug, "sha256", want)
+	return nil
+}
+
 func ArtifactSlug(v, hostOS, hostArch, ext string) string {
 	return fmt.Sprintf("node-%s-%s-%s%s", v, hostOS, hostArch, ext)
 }
@@ -55,6 +178,8 @@ func ArtifactSlug(v, hostOS, hostArch, ext string) string {
 func ExtractArtifact(src, dst string) error {
 	var err error
 
+	slog.Info("extracting", "src", src, "dst", dst)
+
 	switch ext := path.Ext(src); ext {
 	case ".xz": // assume .tar.xz
 		err = extractXZArtifact(src, dst)
@@ -67,10 +192,8 @@ func ExtractArtifact(src, dst string) error {
 	}
 
 	if err != nil {
-		if err := os.Remove(dst); err != nil {
-			return err
-		}
-
+		// a partial extraction is useless, and cleaning it up mustn't hide why it failed
+		os.RemoveAll(dst)
 		return err
 	}
 
@@ -79,8 +202,10 @@ func ExtractArtifact(src, dst string) error {
 
 func extractXZArtifact(src, dst string) error {
 	cmd := exec.Command("tar", "-C", dst, "-xJf", src, "--strip-components=1")
-	if err := cmd.Run(); err != nil {
-		return err
+	slog.Debug("running", "cmd", cmd.String())
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
 	}
 	return nil
 }
@@ -121,6 +246,8 @@ func extractGzipArtifact(src, dst string) error {
 			continue
 		}
 
+		slog.Debug("extracting file", "name", h.Name, "target", target)
+
 		switch h.Typeflag {
 		case tar.TypeDir:
 			if err := os.MkdirAll(target, os.FileMode(h.Mode)); err != nil {
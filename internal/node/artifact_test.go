@@ -0,0 +1,60 @@
+package node
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadArtifact(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v20.0.0/node-v20.0.0-linux-x64.tar.gz":
+			w.Write([]byte("artifact"))
+		case "/v20.0.0/stalled.tar.gz":
+			w.Write([]byte("part"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	distURL, cacheDir, stall := DistURL, CacheDir, downloadStallTimeout
+	t.Cleanup(func() { DistURL, CacheDir, downloadStallTimeout = distURL, cacheDir, stall })
+
+	DistURL = srv.URL
+	CacheDir = t.TempDir()
+	downloadStallTimeout = 200 * time.Millisecond
+
+	a, err := DownloadArtifact("v20.0.0", "node-v20.0.0-linux-x64.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := os.ReadFile(a.Name); string(b) != "artifact" || a.Ext != ".gz" {
+		t.Errorf("downloaded %q to %s", b, a.Name)
+	}
+	os.Remove(a.Name)
+
+	if _, err := DownloadArtifact("v20.0.0", "missing.tar.gz"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing artifact: error = %v", err)
+	}
+
+	start := time.Now()
+	if _, err := DownloadArtifact("v20.0.0", "stalled.tar.gz"); err == nil || !strings.Contains(err.Error(), "no data received") {
+		t.Errorf("stalled download: error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stalled download took %s to give up", elapsed)
+	}
+
+	if entries, _ := os.ReadDir(CacheDir); len(entries) != 0 {
+		t.Errorf("%d files left behind in the cache", len(entries))
+	}
+}
@@ -0,0 +1,110 @@
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	// DistURL is the base URL of the Node distribution, holding the index and the release artifacts.
+	DistURL = "https://nodejs.org/dist"
+	// ScheduleURL is where the Node release schedule is fetched from. file:// URLs are read from disk.
+	ScheduleURL = "https://raw.githubusercontent.com/nodejs/Release/main/schedule.json"
+	// CacheDir is where the remote index and release schedule are cached. Caching is disabled if it's empty.
+	CacheDir string
+	// IndexTTL is how long a cached remote index is used before it's fetched again.
+	IndexTTL = time.Hour
+	// ScheduleTTL is how long a cached release schedule is used before it's fetched again.
+	ScheduleTTL = 24 * time.Hour
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchCached returns the resource at rawURL, using the copy cached under name in CacheDir if it's younger than
+// ttl. If fetching fails, a stale cached copy is returned instead of the error. Local files aren't cached.
+func fetchCached(rawURL, name string, ttl time.Duration) ([]byte, error) {
+	var cachePath string
+	if CacheDir != "" && !strings.HasPrefix(rawURL, "file://") {
+		cachePath = path.Join(CacheDir, cacheName(name, rawURL))
+
+		if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < ttl {
+			if b, err := os.ReadFile(cachePath); err == nil {
+				slog.Info("cache hit", "url", rawURL, "path", cachePath, "age", time.Since(info.ModTime()).Round(time.Second).String())
+				return b, nil
+			}
+		}
+
+		slog.Info("cache miss", "url", rawURL, "path", cachePath)
+	}
+
+	b, err := fetch(rawURL)
+	if err != nil {
+		if cachePath != "" {
+			if stale, staleErr := os.ReadFile(cachePath); staleErr == nil {
+				slog.Warn("using stale cache", "url", rawURL, "path", cachePath, "error", err)
+				return stale, nil
+			}
+		}
+
+		return nil, err
+	}
+
+	if cachePath != "" {
+		// failing to cache only costs a request next time
+		err := os.MkdirAll(CacheDir, 0o755)
+		if err == nil {
+			err = os.WriteFile(cachePath, b, 0o644)
+		}
+
+		if err != nil {
+			slog.Debug("unable to cache", "url", rawURL, "path", cachePath, "error", err)
+		}
+	}
+
+	return b, nil
+}
+
+// cacheName tells apart copies of name fetched from different URLs, e.g. the indexes of different mirrors, by
+// adding a short hash of rawURL before its extension.
+func cacheName(name, rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	ext := path.Ext(name)
+
+	return strings.TrimSuffix(name, ext) + "-" + hex.EncodeToString(sum[:4]) + ext
+}
+
+func fetch(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "file" {
+		slog.Info("reading", "path", u.Path)
+		return os.ReadFile(u.Path)
+	}
+
+	slog.Info("fetching", "url", rawURL)
+	res, err := httpClient.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+	slog.Info("response", "url", rawURL, "status", res.Status)
+
+	if res.StatusCode >= 400 {
+		return nil, fmt.Errorf("request to %s failed with status %s", rawURL, res.Status)
+	}
+
+	return io.ReadAll(res.Body)
+}
@@ -0,0 +1,32 @@
+package node
+
+import (
+	"fmt"
+	"path"
+)
+
+// EnableCorepack runs `corepack enable` with the corepack of the Node installation at versionDir, installing the
+// package manager shims into its bin directory. If no package managers are named, corepack enables all of them.
+func EnableCorepack(r Runner, versionDir string, pms ...string) error {
+	binDir := path.Join(versionDir, "bin")
+	args := append([]string{"enable", "--install-directory", binDir}, pms...)
+
+	if out, err := r.Run(path.Join(binDir, "corepack"), args, versionEnv(versionDir)); err != nil {
+		return fmt.Errorf("corepack enable failed: %w: %s", err, lastLine(string(out)))
+	}
+
+	return nil
+}
+
+// PrepareCorepack downloads the package managers in specs, e.g. "pnpm@9.1.0", with the corepack of the Node
+// installation at versionDir and makes them the default version of their package manager.
+func PrepareCorepack(r Runner, versionDir string, specs ...string) error {
+	binDir := path.Join(versionDir, "bin")
+	args := append([]string{"prepare", "--activate"}, specs...)
+
+	if out, err := r.Run(path.Join(binDir, "corepack"), args, versionEnv(versionDir)); err != nil {
+		return fmt.Errorf("corepack prepare failed: %w: %s", err, lastLine(string(out)))
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package node
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RecordVersionFile notes in the history file at historyPath that the version file at vfPath was used now, so
+// the versions that projects depend on can be told apart from unused ones. Version files that no longer exist are
+// dropped from the history, so it doesn't keep growing.
+func RecordVersionFile(historyPath, vfPath string) error {
+	history, err := readHistory(historyPath)
+	if err != nil {
+		return err
+	}
+
+	for p := range history {
+		if _, err := os.Stat(p); errors.Is(err, fs.ErrNotExist) {
+			delete(history, p)
+		}
+	}
+
+	vfPath, err = filepath.Abs(vfPath)
+	if err != nil {
+		return err
+	}
+
+	history[vfPath] = time.Now()
+
+	paths := make([]string, 0, len(history))
+	for p := range history {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	var b strings.Builder
+	for _, p := range paths {
+		b.WriteString(history[p].UTC().Format(time.RFC3339) + "\t" + p + "\n")
+	}
+
+	return os.WriteFile(historyPath, []byte(b.String()), 0o644)
+}
+
+// RecentVersionFiles returns the version files in the history file at historyPath that were used after since and
+// still exist.
+func RecentVersionFiles(historyPath string, since time.Time) ([]string, error) {
+	history, err := readHistory(historyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []string
+	for p, t := range history {
+		if t.Before(since) {
+			continue
+		}
+
+		if _, err := os.Stat(p); err == nil {
+			recent = append(recent, p)
+		}
+	}
+
+	slices.Sort(recent)
+	return recent, nil
+}
+
+// readHistory returns when each version file in the history file at historyPath was last used. Lines that can't
+// be parsed, e.g. one cut short by an interrupted write, are skipped.
+func readHistory(historyPath string) (map[string]time.Time, error) {
+	history := make(map[string]time.Time)
+
+	f, err := os.Open(historyPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return history, nil
+		}
+
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		stamp, p, ok := strings.Cut(s.Text(), "\t")
+		if !ok {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, stamp)
+		if err != nil {
+			continue
+		}
+
+		history[p] = t
+	}
+
+	return history, s.Err()
+}
@@ -0,0 +1,138 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVersionFileHistory(t *testing.T) {
+	// resolved, since the working directory is below
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := filepath.Join(dir, "version-files")
+
+	a, b := filepath.Join(dir, "a", ".nvmrc"), filepath.Join(dir, "b", ".node-version")
+	for _, p := range []string{a, b} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(p, []byte("20\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if recent, err := RecentVersionFiles(history, time.Time{}); err != nil || len(recent) != 0 {
+		t.Errorf("RecentVersionFiles() without a history = %v, %v, want none", recent, err)
+	}
+
+	start := time.Now().Add(-time.Second)
+
+	for _, p := range []string{b, a, b} {
+		if err := RecordVersionFile(history, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recent, err := RecentVersionFiles(history, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{a, b}; !slices.Equal(recent, want) {
+		t.Errorf("RecentVersionFiles() = %v, want %v", recent, want)
+	}
+
+	// each file is recorded once, however often it's used
+	if content, _ := os.ReadFile(history); strings.Count(string(content), "\n") != 2 {
+		t.Errorf("history is\n%s\nwant a line per version file", content)
+	}
+
+	if recent, _ := RecentVersionFiles(history, time.Now().Add(time.Minute)); len(recent) != 0 {
+		t.Errorf("RecentVersionFiles() in the future = %v, want none", recent)
+	}
+
+	// a relative path is recorded as the absolute one
+	t.Chdir(filepath.Dir(a))
+	if err := RecordVersionFile(history, ".nvmrc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if recent, _ := RecentVersionFiles(history, start); !slices.Equal(recent, []string{a, b}) {
+		t.Errorf("RecentVersionFiles() after recording a relative path = %v", recent)
+	}
+}
+
+func TestVersionFileHistoryDropsMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, "version-files")
+
+	kept, removed := filepath.Join(dir, "kept"), filepath.Join(dir, "removed")
+	for _, p := range []string{kept, removed} {
+		if err := os.WriteFile(p, []byte("20\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := RecordVersionFile(history, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+
+	// a file that's gone isn't recent, even before the history is rewritten
+	if recent, _ := RecentVersionFiles(history, time.Time{}); !slices.Equal(recent, []string{kept}) {
+		t.Errorf("RecentVersionFiles() = %v, want only %s", recent, kept)
+	}
+
+	if err := RecordVersionFile(history, kept); err != nil {
+		t.Fatal(err)
+	}
+
+	if content, _ := os.ReadFile(history); strings.Contains(string(content), removed) {
+		t.Errorf("history still holds the removed file:\n%s", content)
+	}
+}
+
+func TestVersionFileHistoryTruncated(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, "version-files")
+
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte("20\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the last line was cut short in the middle of its timestamp, and another isn't a history line at all
+	stamp := time.Now().UTC().Format(time.RFC3339)
+	content := stamp + "\t" + a + "\nnot a history line\n" + stamp[:10]
+	if err := os.WriteFile(history, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if recent, err := RecentVersionFiles(history, time.Time{}); err != nil || !slices.Equal(recent, []string{a}) {
+		t.Errorf("RecentVersionFiles() = %v, %v, want only %s", recent, err, a)
+	}
+
+	if err := RecordVersionFile(history, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if recent, _ := RecentVersionFiles(history, time.Time{}); !slices.Equal(recent, []string{a, b}) {
+		t.Errorf("RecentVersionFiles() after recording = %v, want %s and %s", recent, a, b)
+	}
+
+	if content, _ := os.ReadFile(history); strings.Contains(string(content), "not a history line") {
+		t.Errorf("history kept the broken lines:\n%s", content)
+	}
+}
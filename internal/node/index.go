@@ -1,16 +1,26 @@
 package node
 
 import (
-	"io"
-	"net/http"
+	"fmt"
+	"net/url"
 	"os"
 	"slices"
-	"strconv"
 	"strings"
+	"time"
+
+	"github.com/aronhoyer/go-nvm/internal/semver"
 )
 
 type IndexEntry struct {
-	Version, LTS string
+	Version string `json:"version"`
+	LTS     string `json:"lts"`
+	// Date is the release date, formatted YYYY-MM-DD. Only set for entries in the remote index.
+	Date string `json:"date,omitempty"`
+	// Security is true if the release fixes security issues. Only set for entries in the remote index.
+	Security bool `json:"security"`
+	// Files are the platforms the release has builds for, e.g. "linux-x64" or "win-x64-zip". Only set for entries
+	// in the remote index.
+	Files []string `json:"files,omitempty"`
 }
 
 func GetRemoteIndex() ([]IndexEntry, error) {
@@ -28,13 +38,12 @@ func GetRemoteIndex() ([]IndexEntry, error) {
 	//
 	// Long live raw data.
 
-	res, err := http.Get("https://nodejs.org/dist/index.tab")
+	u, err := url.JoinPath(DistURL, "index.tab")
 	if err != nil {
 		return nil, err
 	}
 
-	defer res.Body.Close()
-	b, err := io.ReadAll(res.Body)
+	b, err := fetchCached(u, "index.tab", IndexTTL)
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +63,8 @@ func GetRemoteIndex() ([]IndexEntry, error) {
 	return idx, nil
 }
 
+// GetLocalIndex returns the versions installed in idxPath, newest first. Entries that aren't version directories
+// are skipped.
 func GetLocalIndex(idxPath string) ([]IndexEntry, error) {
 	entries, err := os.ReadDir(idxPath)
 	if err != nil {
@@ -63,31 +74,15 @@ func GetLocalIndex(idxPath string) ([]IndexEntry, error) {
 	var idxEntries []IndexEntry
 
 	for _, entry := range entries {
+		if _, err := semver.Parse(entry.Name()); err != nil || !entry.IsDir() {
+			continue
+		}
+
 		idxEntries = append(idxEntries, IndexEntry{Version: entry.Name()})
 	}
 
 	slices.SortFunc(idxEntries, func(a, b IndexEntry) int {
-		// just to cover all cases. this should never happen (tm)
-		if a.Version == b.Version {
-			return 0
-		}
-
-		av := strings.SplitN(strings.TrimPrefix(a.Version, "v"), ".", 3)
-		bv := strings.SplitN(strings.TrimPrefix(b.Version, "v"), ".", 3)
-
-		amaj, _ := strconv.Atoi(av[0])
-		amin, _ := strconv.Atoi(av[1])
-		ap, _ := strconv.Atoi(av[2])
-
-		bmaj, _ := strconv.Atoi(bv[0])
-		bmin, _ := strconv.Atoi(bv[1])
-		bp, _ := strconv.Atoi(bv[2])
-
-		if amaj > bmaj || amin > bmin || ap > bp {
-			return -1
-		} else {
-			return 1
-		}
+		return semver.Compare(b.Version, a.Version)
 	})
 
 	return idxEntries, nil
@@ -96,12 +91,99 @@ func GetLocalIndex(idxPath string) ([]IndexEntry, error) {
 func parseIndexLine(line string) (IndexEntry, error) {
 	// version	date	files	npm	v8	uv	zlib	openssl	modules	lts	security
 	parts := strings.Fields(line)
+	if len(parts) < 11 {
+		return IndexEntry{}, fmt.Errorf("malformed index line: %q", line)
+	}
 
-	version, lts := parts[0], parts[9]
+	version, date, files, lts, security := parts[0], parts[1], parts[2], parts[9], parts[10]
 
 	if lts == "-" {
 		lts = ""
 	}
 
-	return IndexEntry{version, lts}, nil
+	return IndexEntry{version, lts, date, security == "true", strings.Split(files, ",")}, nil
+}
+
+// PlatformFile returns the entry of the files field of the remote index for the builds nvm installs on hostOS and
+// hostArch, as returned by [platform.SysInfoNorm].
+func PlatformFile(hostOS, hostArch string) string {
+	switch hostOS {
+	case "win":
+		return "win-" + hostArch + "-zip"
+	case "darwin":
+		return "osx-" + hostArch + "-tar"
+	default:
+		return hostOS + "-" + hostArch
+	}
+}
+
+// IndexFilter selects entries of the remote index. The zero IndexFilter selects every entry.
+type IndexFilter struct {
+	// LTS selects the releases of an LTS line by codename, or of any LTS line if it's "*".
+	LTS string
+	// Range selects the versions in a semver range, e.g. "20" or ">=18 <21".
+	Range string
+	// Since selects the releases made on or after a date, formatted YYYY-MM-DD.
+	Since string
+	// SecurityOnly selects the releases that fix security issues.
+	SecurityOnly bool
+	// Platform selects the releases with a build for a platform, as returned by [PlatformFile].
+	Platform string
+	// LatestPerMajor keeps only the newest selected release of each major version.
+	LatestPerMajor bool
+	// Offset skips the newest Offset selected releases, and Limit keeps only the next Limit, if it's positive.
+	Offset, Limit int
+}
+
+// FilterIndex returns the entries of idx, the remote index, that f selects, in the same order.
+func FilterIndex(idx []IndexEntry, f IndexFilter) ([]IndexEntry, error) {
+	var r semver.Range
+	if f.Range != "" {
+		var err error
+		if r, err = semver.ParseRange(f.Range); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.Since != "" {
+		if _, err := time.Parse(time.DateOnly, f.Since); err != nil {
+			return nil, fmt.Errorf("%w: %q is not a date, expected YYYY-MM-DD", ErrInvalidFilter, f.Since)
+		}
+	}
+
+	var filtered []IndexEntry
+	majors := make(map[int]bool)
+	skipped := 0
+
+	for _, e := range idx {
+		switch {
+		case f.LTS == "*" && e.LTS == "",
+			f.LTS != "" && f.LTS != "*" && !strings.EqualFold(e.LTS, f.LTS),
+			f.Range != "" && !r.ContainsString(e.Version),
+			f.Since != "" && e.Date < f.Since,
+			f.SecurityOnly && !e.Security,
+			f.Platform != "" && !slices.Contains(e.Files, f.Platform):
+			continue
+		}
+
+		if f.LatestPerMajor {
+			v, err := semver.Parse(e.Version)
+			if err != nil || majors[v.Major] {
+				continue
+			}
+			majors[v.Major] = true
+		}
+
+		if skipped < f.Offset {
+			skipped++
+			continue
+		}
+
+		filtered = append(filtered, e)
+		if len(filtered) == f.Limit {
+			break
+		}
+	}
+
+	return filtered, nil
 }
@@ -0,0 +1,74 @@
+package node
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// testIndex is a remote index, newest first, as it's served.
+var testIndex = []IndexEntry{
+	{Version: "v22.1.0", Date: "2024-05-02", Files: []string{"linux-x64", "osx-arm64-tar"}},
+	{Version: "v22.0.0", Date: "2024-04-24", Security: true, Files: []string{"linux-x64"}},
+	{Version: "v20.12.2", LTS: "Iron", Date: "2024-04-10", Security: true, Files: []string{"linux-x64", "osx-arm64-tar"}},
+	{Version: "v20.12.1", LTS: "Iron", Date: "2024-04-03", Files: []string{"linux-x64"}},
+	{Version: "v18.20.2", LTS: "Hydrogen", Date: "2024-04-10", Security: true, Files: []string{"linux-x64"}},
+	{Version: "v18.20.1", LTS: "Hydrogen", Date: "2024-04-03", Files: []string{"linux-x64"}},
+	{Version: "v19.9.0", Date: "2023-04-10", Files: []string{"linux-x64"}},
+}
+
+func TestFilterIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter IndexFilter
+		want   []string
+	}{
+		{"none", IndexFilter{}, []string{"v22.1.0", "v22.0.0", "v20.12.2", "v20.12.1", "v18.20.2", "v18.20.1", "v19.9.0"}},
+		{"any lts", IndexFilter{LTS: "*"}, []string{"v20.12.2", "v20.12.1", "v18.20.2", "v18.20.1"}},
+		{"lts codename", IndexFilter{LTS: "hydrogen"}, []string{"v18.20.2", "v18.20.1"}},
+		{"unknown codename", IndexFilter{LTS: "argon"}, nil},
+		{"range", IndexFilter{Range: ">=19 <22"}, []string{"v20.12.2", "v20.12.1", "v19.9.0"}},
+		{"lts and range", IndexFilter{LTS: "*", Range: "18"}, []string{"v18.20.2", "v18.20.1"}},
+		{"codename outside range", IndexFilter{LTS: "iron", Range: "18"}, nil},
+		{"since", IndexFilter{Since: "2024-04-10"}, []string{"v22.1.0", "v22.0.0", "v20.12.2", "v18.20.2"}},
+		{"security", IndexFilter{SecurityOnly: true}, []string{"v22.0.0", "v20.12.2", "v18.20.2"}},
+		{"platform", IndexFilter{Platform: "osx-arm64-tar"}, []string{"v22.1.0", "v20.12.2"}},
+		{"latest per major", IndexFilter{LatestPerMajor: true}, []string{"v22.1.0", "v20.12.2", "v18.20.2", "v19.9.0"}},
+		{"limit", IndexFilter{Limit: 2}, []string{"v22.1.0", "v22.0.0"}},
+		{"offset", IndexFilter{Offset: 5}, []string{"v18.20.1", "v19.9.0"}},
+		{"offset and limit", IndexFilter{Offset: 1, Limit: 2}, []string{"v22.0.0", "v20.12.2"}},
+		{"offset past end", IndexFilter{Offset: 10}, nil},
+		{"lts and limit", IndexFilter{LTS: "*", Limit: 1}, []string{"v20.12.2"}},
+		{"range and limit", IndexFilter{Range: "20", Limit: 5}, []string{"v20.12.2", "v20.12.1"}},
+		{"codename, range and limit", IndexFilter{LTS: "Iron", Range: ">=20.12", Offset: 1, Limit: 1}, []string{"v20.12.1"}},
+		{"latest per major and limit", IndexFilter{LTS: "*", LatestPerMajor: true, Limit: 1}, []string{"v20.12.2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterIndex(testIndex, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var versions []string
+			for _, e := range got {
+				versions = append(versions, e.Version)
+			}
+
+			if !slices.Equal(versions, tt.want) {
+				t.Errorf("FilterIndex(%+v) = %v, want %v", tt.filter, versions, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterIndexErrors(t *testing.T) {
+	if _, err := FilterIndex(testIndex, IndexFilter{Range: "iron"}); err == nil {
+		t.Error("invalid range: no error")
+	}
+
+	if _, err := FilterIndex(testIndex, IndexFilter{Since: "10 April 2024"}); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("invalid date: error = %v, want %v", err, ErrInvalidFilter)
+	}
+}
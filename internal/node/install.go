@@ -1,18 +1,14 @@
 package node
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"path"
-)
 
-func Install(version string) error {
-	nvmDir := os.Getenv("NVMDIR")
-	if nvmDir == "" {
-		return errors.New("environment variable NVMDIR not set")
-	}
+	"github.com/aronhoyer/go-nvm/internal/paths"
+)
 
+func Install(p paths.Paths, version string) error {
 	fmt.Printf("Installing Node %s...\n", version)
 
 	fmt.Printf("Downloading %s artifact...\n", version)
@@ -27,7 +23,7 @@ func Install(version string) error {
 
 	fmt.Println("Extracting artifact...")
 
-	nodeVersionInstallPath := path.Join(nvmDir, "versions", version)
+	nodeVersionInstallPath := p.Version(version)
 
 	if err := ExtractArtifact(artifact.Name, nodeVersionInstallPath); err != nil {
 		return err
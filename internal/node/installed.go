@@ -0,0 +1,192 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aronhoyer/go-nvm/internal/paths"
+	"github.com/aronhoyer/go-nvm/internal/semver"
+)
+
+// InstalledVersion describes an installed version. It's the schema of the JSON output of the commands that print
+// installed versions, so fields are only ever added to it.
+type InstalledVersion struct {
+	Version string `json:"version"`
+	// LTS is the codename of the LTS line of the version, or empty if it isn't an LTS release.
+	LTS string `json:"lts"`
+	// Active is true if the bin link points to the version.
+	Active bool `json:"active"`
+	// Default is true if the default alias resolves to the version.
+	Default bool `json:"default"`
+	// Aliases are the aliases resolving to the version.
+	Aliases []string `json:"aliases"`
+	// Path is the installation directory.
+	Path string `json:"path"`
+	// Node is the path of the node executable.
+	Node string `json:"node"`
+	// Npm is the npm version installed in place of the bundled one, if any.
+	Npm string `json:"npm,omitempty"`
+	// InstalledAt is when the version was installed.
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// ResolvedAlias is an alias with the installed version it resolves to.
+type ResolvedAlias struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	// Version is the installed version the alias resolves to, or empty if it doesn't resolve to one.
+	Version string `json:"version"`
+}
+
+// ResolveAliases returns every alias with the installed version it resolves to.
+func ResolveAliases(p paths.Paths) ([]ResolvedAlias, error) {
+	aliases, err := ListAliases(p.Aliases())
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]ResolvedAlias, len(aliases))
+	for i, a := range aliases {
+		resolved[i] = ResolvedAlias{Name: a.Name, Target: a.Target}
+		if v, err := ResolveLocal(p, a.Name); err == nil {
+			resolved[i].Version = v
+		}
+	}
+
+	return resolved, nil
+}
+
+// ListInstalled describes every installed version, newest first.
+func ListInstalled(p paths.Paths) ([]InstalledVersion, error) {
+	idx, err := GetLocalIndex(p.Versions())
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := ResolveAliases(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ltsNames, err := localLTSNames(p)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := ReadActiveVersion(p.Bin())
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]InstalledVersion, 0, len(idx))
+	for _, e := range idx {
+		v := InstalledVersion{
+			Version: e.Version,
+			Active:  e.Version == active,
+			Aliases: []string{},
+			Path:    p.Version(e.Version),
+			Node:    path.Join(p.Version(e.Version), "bin", "node"),
+		}
+
+		if sv, err := semver.Parse(e.Version); err == nil {
+			v.LTS = ltsNames[sv.Major]
+		}
+
+		for _, a := range aliases {
+			if a.Version == e.Version {
+				v.Aliases = append(v.Aliases, a.Name)
+				v.Default = v.Default || a.Name == "default"
+			}
+		}
+
+		meta, err := ReadMetadata(v.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if meta.Npm != nil {
+			v.Npm = meta.Npm.Version
+		}
+
+		// versions installed before the install time was recorded fall back to the time their directory was made
+		v.InstalledAt = meta.InstalledAt
+		if v.InstalledAt.IsZero() {
+			if info, err := os.Stat(v.Path); err == nil {
+				v.InstalledAt = info.ModTime()
+			}
+		}
+
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
+// GetInstalled describes the installed version. It returns [ErrUnknownVersion] if version isn't installed.
+func GetInstalled(p paths.Paths, version string) (InstalledVersion, error) {
+	versions, err := ListInstalled(p)
+	if err != nil {
+		return InstalledVersion{}, err
+	}
+
+	for _, v := range versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+
+	return InstalledVersion{}, fmt.Errorf("%w: %s", ErrUnknownVersion, version)
+}
+
+// ReadActiveVersion returns the version the bin link at binPath points to, or an empty string if no version is
+// active.
+func ReadActiveVersion(binPath string) (string, error) {
+	target, err := os.Readlink(binPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	// the link points to versions/<VERSION>/bin
+	return path.Base(path.Dir(target)), nil
+}
+
+// localLTSNames maps the major versions of the LTS lines in the lts directory to their codenames.
+func localLTSNames(p paths.Paths) (map[int]string, error) {
+	names := make(map[int]string)
+
+	entries, err := os.ReadDir(p.LTS())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return names, nil
+		}
+
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.Name() == "latest" || !e.Type().IsRegular() {
+			continue
+		}
+
+		b, err := os.ReadFile(path.Join(p.LTS(), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if v, err := semver.Parse(strings.TrimSpace(string(b))); err == nil {
+			// codenames are stored lowercase, but Node capitalizes them
+			names[v.Major] = strings.ToUpper(e.Name()[:1]) + e.Name()[1:]
+		}
+	}
+
+	return names, nil
+}
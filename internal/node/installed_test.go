@@ -0,0 +1,88 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestListInstalled(t *testing.T) {
+	p := testPaths(t)
+
+	installedAt := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	if err := WriteMetadata(p.Version("v20.12.2"), Metadata{Npm: &NpmPin{"^10", "10.5.2"}, InstalledAt: installedAt}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(path.Join(p.Version("v22.1.0"), "bin"), p.Bin()); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := ListInstalled(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, v := range versions {
+		names = append(names, v.Version)
+	}
+
+	if want := []string{"v22.1.0", "v20.12.2", "v20.1.0", "v18.20.2", "v18.1.0"}; !slices.Equal(names, want) {
+		t.Fatalf("ListInstalled() = %v, want %v", names, want)
+	}
+
+	tests := []struct {
+		got, want InstalledVersion
+	}{
+		{versions[0], InstalledVersion{Version: "v22.1.0", Active: true, Aliases: []string{}}},
+		{versions[1], InstalledVersion{Version: "v20.12.2", LTS: "Iron", Aliases: []string{}, Npm: "10.5.2", InstalledAt: installedAt}},
+		{versions[2], InstalledVersion{Version: "v20.1.0", LTS: "Iron", Default: true, Aliases: []string{"default"}}},
+		{versions[3], InstalledVersion{Version: "v18.20.2", LTS: "Hydrogen", Aliases: []string{"old", "work"}}},
+		{versions[4], InstalledVersion{Version: "v18.1.0", LTS: "Hydrogen", Aliases: []string{}}},
+	}
+
+	for _, tt := range tests {
+		got, want := tt.got, tt.want
+		if got.Version != want.Version || got.LTS != want.LTS || got.Active != want.Active || got.Default != want.Default ||
+			!slices.Equal(got.Aliases, want.Aliases) || got.Npm != want.Npm {
+			t.Errorf("%s = %+v, want %+v", want.Version, got, want)
+		}
+
+		if got.Path != p.Version(want.Version) || got.Node != path.Join(p.Version(want.Version), "bin", "node") {
+			t.Errorf("%s: paths are %s and %s", want.Version, got.Path, got.Node)
+		}
+
+		// versions without a recorded install time fall back to when their directory was made
+		if !want.InstalledAt.IsZero() && !got.InstalledAt.Equal(want.InstalledAt) || got.InstalledAt.IsZero() {
+			t.Errorf("%s: InstalledAt = %s", want.Version, got.InstalledAt)
+		}
+	}
+
+	if v, err := GetInstalled(p, "v18.1.0"); err != nil || v.Version != "v18.1.0" {
+		t.Errorf("GetInstalled(v18.1.0) = %+v, %v", v, err)
+	}
+
+	if _, err := GetInstalled(p, "v19.0.0"); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("GetInstalled(v19.0.0) error = %v, want %v", err, ErrUnknownVersion)
+	}
+}
+
+func TestListInstalledEmpty(t *testing.T) {
+	p := testPaths(t)
+	if err := os.RemoveAll(p.Versions()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(p.Versions(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing's installed, but the JSON output is still a list
+	if versions, err := ListInstalled(p); err != nil || versions == nil || len(versions) != 0 {
+		t.Errorf("ListInstalled() = %#v, %v, want an empty list", versions, err)
+	}
+}
@@ -4,16 +4,12 @@ import (
 	"errors"
 	"io/fs"
 	"os"
-	"path"
-)
 
-func VersionIsInstalled(version string) (bool, error) {
-	nvmDir := os.Getenv("NVMDIR")
-	if nvmDir == "" {
-		return false, errors.New("environment variable NVMDIR not set")
-	}
+	"github.com/aronhoyer/go-nvm/internal/paths"
+)
 
-	_, err := os.Stat(path.Join(nvmDir, "versions", version))
+func VersionIsInstalled(p paths.Paths, version string) (bool, error) {
+	_, err := os.Stat(p.Version(version))
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
 			return false, nil
@@ -22,5 +18,5 @@ func VersionIsInstalled(version string) (bool, error) {
 		return false, err
 	}
 
-	return false, nil
+	return true, nil
 }
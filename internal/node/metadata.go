@@ -0,0 +1,54 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+)
+
+// metadataFile is stored in the root of every installed version, next to the files of the Node distribution.
+const metadataFile = ".nvm-metadata.json"
+
+// Metadata is what nvm knows about an installed version beyond what's in the Node distribution itself.
+type Metadata struct {
+	Npm *NpmPin `json:"npm,omitempty"`
+	// InstalledAt is when the version was installed. It's zero for versions installed before it was recorded.
+	InstalledAt time.Time `json:"installedAt,omitzero"`
+}
+
+// NpmPin records the npm version installed in place of the one bundled with Node.
+type NpmPin struct {
+	Range   string `json:"range"`
+	Version string `json:"version"`
+}
+
+// ReadMetadata reads the metadata of the installed version at versionDir. A version without metadata has the
+// zero Metadata.
+func ReadMetadata(versionDir string) (Metadata, error) {
+	var m Metadata
+
+	b, err := os.ReadFile(path.Join(versionDir, metadataFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return m, nil
+		}
+
+		return m, err
+	}
+
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// WriteMetadata replaces the metadata of the installed version at versionDir.
+func WriteMetadata(versionDir string, m Metadata) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(versionDir, metadataFile), append(b, '\n'), 0o644)
+}
@@ -0,0 +1,71 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/aronhoyer/go-nvm/internal/semver"
+)
+
+var ErrNpmMismatch = errors.New("installed npm does not match requested version")
+
+// NpmVersion returns the version of the npm installed in the Node installation at versionDir.
+func NpmVersion(versionDir string) (string, error) {
+	b, err := os.ReadFile(path.Join(versionDir, "lib", "node_modules", "npm", "package.json"))
+	if err != nil {
+		return "", err
+	}
+
+	var pkg struct {
+		Version string `json:"version"`
+	}
+
+	if err := json.Unmarshal(b, &pkg); err != nil {
+		return "", err
+	}
+
+	return pkg.Version, nil
+}
+
+// InstallNpm replaces the npm of the Node installation at versionDir with the newest npm matching rangeSpec, which
+// is either a version range or "latest". The installed version is verified and pinned in the version's metadata.
+func InstallNpm(r Runner, versionDir, rangeSpec string) (string, error) {
+	var rng *semver.Range
+	if rangeSpec != "latest" {
+		parsed, err := semver.ParseRange(rangeSpec)
+		if err != nil {
+			return "", err
+		}
+		rng = &parsed
+	}
+
+	res := InstallGlobalPackages(r, versionDir, []string{"npm@" + rangeSpec})
+	if err := res[0].Err; err != nil {
+		return "", err
+	}
+
+	version, err := NpmVersion(versionDir)
+	if err != nil {
+		return "", fmt.Errorf("unable to read installed npm version: %w", err)
+	}
+
+	if rng != nil && !rng.ContainsString(version) {
+		return "", fmt.Errorf("%w: %s is not in %s", ErrNpmMismatch, version, rangeSpec)
+	}
+
+	meta, err := ReadMetadata(versionDir)
+	if err != nil {
+		return "", err
+	}
+
+	meta.Npm = &NpmPin{Range: rangeSpec, Version: version}
+
+	if err := WriteMetadata(versionDir, meta); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
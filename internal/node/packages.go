@@ -0,0 +1,161 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// bundledPackages ship with every Node release and are managed by the release itself.
+var bundledPackages = []string{"npm", "corepack"}
+
+type PackageResult struct {
+	Name string
+	Err  error
+}
+
+// GlobalPackages returns the names of the packages installed globally in the Node installation at versionDir,
+// excluding the ones bundled with Node. Packages that are symlinks, e.g. created by `npm link`, are returned
+// separately in linked, since they can't be installed from the registry.
+func GlobalPackages(versionDir string) (pkgs, linked []string, err error) {
+	modulesDir := path.Join(versionDir, "lib", "node_modules")
+
+	entries, err := os.ReadDir(modulesDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, nil
+		}
+
+		return nil, nil, err
+	}
+
+	add := func(name string, e fs.DirEntry) {
+		if e.Type()&fs.ModeSymlink != 0 {
+			linked = append(linked, name)
+		} else {
+			pkgs = append(pkgs, name)
+		}
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+
+		switch {
+		case strings.HasPrefix(name, "."):
+			continue
+		case strings.HasPrefix(name, "@"):
+			scoped, err := os.ReadDir(path.Join(modulesDir, name))
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for _, se := range scoped {
+				add(name+"/"+se.Name(), se)
+			}
+		default:
+			isBundled := false
+			for _, b := range bundledPackages {
+				if name == b {
+					isBundled = true
+					break
+				}
+			}
+
+			if !isBundled {
+				add(name, e)
+			}
+		}
+	}
+
+	return pkgs, linked, nil
+}
+
+// Runner runs an external program and returns its combined output. It lets the npm invocations be replaced,
+// e.g. by a fake npm.
+type Runner interface {
+	Run(name string, args, env []string) ([]byte, error)
+}
+
+// ExecRunner is the Runner that executes programs with [os/exec].
+type ExecRunner struct{}
+
+func (ExecRunner) Run(name string, args, env []string) ([]byte, error) {
+	var out bytes.Buffer
+
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	slog.Info("running", "cmd", cmd.String())
+	err := cmd.Run()
+	return out.Bytes(), err
+}
+
+// InstallGlobalPackages installs each package spec globally with the npm of the Node installation at versionDir.
+// Packages are installed one at a time, so a failing package doesn't prevent the others from being installed.
+func InstallGlobalPackages(r Runner, versionDir string, specs []string) []PackageResult {
+	binDir := path.Join(versionDir, "bin")
+	env := versionEnv(versionDir)
+
+	results := make([]PackageResult, 0, len(specs))
+
+	for _, spec := range specs {
+		var err error
+
+		args := []string{"install", "--global", "--no-fund", "--no-audit", spec}
+		if out, runErr := r.Run(path.Join(binDir, "npm"), args, env); runErr != nil {
+			err = fmt.Errorf("%w: %s", runErr, lastLine(string(out)))
+		}
+
+		results = append(results, PackageResult{spec, err})
+	}
+
+	return results
+}
+
+// ReadDefaultPackages reads the package specs in a default packages file, one per line. Blank lines and comments
+// are ignored. A missing file holds no packages.
+func ReadDefaultPackages(p string) ([]string, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var specs []string
+	for _, line := range strings.Split(string(b), "\n") {
+		// a line may hold several specs, like the arguments to `npm install`. "#" only starts a comment at the
+		// beginning of a field, since specs like github:user/repo#branch use it too.
+		for _, field := range strings.Fields(line) {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+
+			specs = append(specs, field)
+		}
+	}
+
+	return specs, nil
+}
+
+// versionEnv returns the environment with the bin directory of the Node installation at versionDir first in PATH,
+// so scripts run with that installation's node.
+func versionEnv(versionDir string) []string {
+	binDir := path.Join(versionDir, "bin")
+	return append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
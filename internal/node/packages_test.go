@@ -0,0 +1,150 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeNpm is a Runner standing in for npm. Installing a package in fail fails with npm's output ending in the
+// package name.
+type fakeNpm struct {
+	calls [][]string
+	fail  []string
+}
+
+func (f *fakeNpm) Run(name string, args, env []string) ([]byte, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+
+	if spec := args[len(args)-1]; slices.Contains(f.fail, spec) {
+		return []byte("npm error code E404\nnpm error 404 Not Found - " + spec + "\n"), errors.New("exit status 1")
+	}
+
+	return []byte("added 1 package\n"), nil
+}
+
+func TestInstallGlobalPackages(t *testing.T) {
+	npm := &fakeNpm{fail: []string{"does-not-exist"}}
+	specs := []string{"typescript", "does-not-exist", "@scope/pkg@1.2.3"}
+
+	results := InstallGlobalPackages(npm, "/versions/v20.11.1", specs)
+
+	if len(results) != len(specs) {
+		t.Fatalf("got %d results, want %d", len(results), len(specs))
+	}
+
+	for i, r := range results {
+		if r.Name != specs[i] {
+			t.Errorf("results[%d].Name = %s, want %s", i, r.Name, specs[i])
+		}
+
+		if failed := r.Err != nil; failed != (specs[i] == "does-not-exist") {
+			t.Errorf("results[%d].Err = %v", i, r.Err)
+		}
+	}
+
+	// the error carries the last line of npm's output
+	if err := results[1].Err; err == nil || !strings.Contains(err.Error(), "404 Not Found - does-not-exist") {
+		t.Errorf("error = %v, want npm's last line", err)
+	}
+
+	// every package is installed, with the installation's own npm, after a failing one too
+	if len(npm.calls) != len(specs) {
+		t.Fatalf("npm ran %d times, want %d", len(npm.calls), len(specs))
+	}
+
+	for i, call := range npm.calls {
+		if call[0] != "/versions/v20.11.1/bin/npm" {
+			t.Errorf("ran %s, want the npm of the version", call[0])
+		}
+
+		if call[len(call)-1] != specs[i] || !slices.Contains(call, "--global") {
+			t.Errorf("ran %v, want a global install of %s", call, specs[i])
+		}
+	}
+}
+
+func TestReadDefaultPackages(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"empty", "", nil},
+		{"one per line", "typescript\neslint\n", []string{"typescript", "eslint"}},
+		{"blank lines", "\n  typescript\n\n\teslint  \n\n", []string{"typescript", "eslint"}},
+		{"comments", "# tools\ntypescript # the compiler\n  # indented\n", []string{"typescript"}},
+		{"hash in spec", "github:user/repo#branch\n", []string{"github:user/repo#branch"}},
+		{"several per line", "typescript eslint@8 prettier # formatting\n", []string{"typescript", "eslint@8", "prettier"}},
+		{"crlf", "typescript\r\neslint\r\n", []string{"typescript", "eslint"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "default-packages")
+			if err := os.WriteFile(p, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadDefaultPackages(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ReadDefaultPackages() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		got, err := ReadDefaultPackages(filepath.Join(t.TempDir(), "default-packages"))
+		if err != nil || got != nil {
+			t.Errorf("ReadDefaultPackages() = %q, %v, want no packages", got, err)
+		}
+	})
+}
+
+func TestGlobalPackages(t *testing.T) {
+	versionDir := t.TempDir()
+	modules := filepath.Join(versionDir, "lib", "node_modules")
+
+	for _, dir := range []string{"npm", "corepack", "typescript", ".bin", "@scope/tool", "@scope/other"} {
+		if err := os.MkdirAll(filepath.Join(modules, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := t.TempDir()
+	for _, link := range []string{"my-lib", "@scope/linked"} {
+		if err := os.Symlink(src, filepath.Join(modules, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, linked, err := GlobalPackages(versionDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(pkgs)
+	slices.Sort(linked)
+
+	if want := []string{"@scope/other", "@scope/tool", "typescript"}; !slices.Equal(pkgs, want) {
+		t.Errorf("pkgs = %q, want %q", pkgs, want)
+	}
+
+	if want := []string{"@scope/linked", "my-lib"}; !slices.Equal(linked, want) {
+		t.Errorf("linked = %q, want %q", linked, want)
+	}
+}
+
+func TestGlobalPackagesNoModules(t *testing.T) {
+	pkgs, linked, err := GlobalPackages(t.TempDir())
+	if err != nil || pkgs != nil || linked != nil {
+		t.Errorf("GlobalPackages() = %q, %q, %v, want nothing", pkgs, linked, err)
+	}
+}
@@ -0,0 +1,245 @@
+package node
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aronhoyer/go-nvm/internal/paths"
+	"github.com/aronhoyer/go-nvm/internal/semver"
+)
+
+var (
+	ErrNotInstalled   = errors.New("no installed version matches")
+	ErrUnknownVersion = errors.New("unknown version")
+	ErrNoVersionFile  = errors.New("no version file found")
+	ErrInvalidFilter  = errors.New("invalid filter")
+)
+
+// SystemVersion is the pseudo-version of the Node installed outside of NVMDIR, e.g. by the OS package manager.
+const SystemVersion = "system"
+
+// VersionFiles are the file names, in order of precedence, that may pin a Node version for a project.
+var VersionFiles = []string{".nvmrc", ".node-version"}
+
+// maxAliasDepth guards against aliases that (directly or indirectly) point to themselves.
+const maxAliasDepth = 8
+
+// ResolveLocal resolves spec to the newest installed version it matches and returns its version string, e.g.
+// "v20.11.1".
+//
+// spec may be a version or version prefix ("20", "v20.11"), a range (">=18 <20", "^18.2"), "latest" or "node" for
+// the newest installed version, "lts" or "lts/*" for the latest LTS line, "lts/<codename>" or a bare codename for
+// a specific LTS line, "system", or the name of an alias. If spec resolves to the system Node, [SystemVersion] is
+// returned.
+func ResolveLocal(p paths.Paths, spec string) (string, error) {
+	idx, err := GetLocalIndex(p.Versions())
+	if err != nil {
+		return "", err
+	}
+
+	return resolveLocal(p, idx, spec, 0)
+}
+
+func resolveLocal(p paths.Paths, idx []IndexEntry, spec string, depth int) (string, error) {
+	if depth > maxAliasDepth {
+		return "", fmt.Errorf("%w: alias loop detected while resolving %s", ErrUnknownVersion, spec)
+	}
+
+	spec = strings.ToLower(strings.TrimSpace(spec))
+
+	switch spec {
+	case "":
+		return "", fmt.Errorf("%w: empty version", ErrUnknownVersion)
+	case SystemVersion:
+		return SystemVersion, nil
+	case "latest", "node":
+		return newestInstalled(idx, "*", spec)
+	case "lts", "lts/*":
+		spec = "lts/latest"
+	}
+
+	ltsName := strings.TrimPrefix(spec, "lts/")
+	if b, err := os.ReadFile(path.Join(p.LTS(), ltsName)); err == nil {
+		// the lts file holds the newest remote release of that line, which is not necessarily the one installed
+		v, err := semver.Parse(strings.TrimSpace(string(b)))
+		if err != nil {
+			return "", fmt.Errorf("%w: corrupt lts file for %s", ErrUnknownVersion, ltsName)
+		}
+
+		return newestInstalled(idx, fmt.Sprintf("%d", v.Major), spec)
+	} else if strings.HasPrefix(spec, "lts/") {
+		return "", fmt.Errorf("%w: %s", ErrUnknownVersion, spec)
+	}
+
+	if target, err := ReadAlias(p.Aliases(), spec); err == nil {
+		return resolveLocal(p, idx, target, depth+1)
+	}
+
+	return newestInstalled(idx, spec, spec)
+}
+
+// ResolveAlias follows spec through the aliases it names, and returns the first spec that isn't an alias. Specs
+// that aren't aliases are returned as is.
+func ResolveAlias(p paths.Paths, spec string) (string, error) {
+	for depth := 0; depth <= maxAliasDepth; depth++ {
+		target, err := ReadAlias(p.Aliases(), strings.ToLower(strings.TrimSpace(spec)))
+		if err != nil {
+			return spec, nil
+		}
+
+		spec = target
+	}
+
+	return "", fmt.Errorf("%w: alias loop detected while resolving %s", ErrUnknownVersion, spec)
+}
+
+func newestInstalled(idx []IndexEntry, rangeSpec, spec string) (string, error) {
+	r, err := semver.ParseRange(rangeSpec)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownVersion, spec)
+	}
+
+	var newest string
+	for _, e := range idx {
+		if r.ContainsString(e.Version) && (newest == "" || semver.Compare(e.Version, newest) > 0) {
+			newest = e.Version
+		}
+	}
+
+	if newest == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotInstalled, spec)
+	}
+
+	return newest, nil
+}
+
+// FindVersionFile walks from dir up to the filesystem root and returns the path of the first version file it
+// finds. See [VersionFiles].
+func FindVersionFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range VersionFiles {
+			p := filepath.Join(dir, name)
+			if info, err := os.Stat(p); err == nil && !info.IsDir() {
+				return p, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrNoVersionFile
+		}
+		dir = parent
+	}
+}
+
+// ReadVersionFile returns the version spec in a version file, i.e. its first line that is neither blank nor a
+// comment.
+func ReadVersionFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("%w: %s is empty", ErrUnknownVersion, p)
+}
+
+// ResolveRemote resolves spec to the newest release in idx, the remote index, that it matches. spec may be a
+// version, version prefix or range, "latest" or "node" for the newest release, "lts" or "lts/*" for the newest
+// LTS release, or an LTS codename with or without the "lts/" prefix.
+func ResolveRemote(idx []IndexEntry, spec string) (IndexEntry, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+
+	var match func(e IndexEntry) bool
+
+	switch spec {
+	case "", "latest", "node":
+		match = func(e IndexEntry) bool { return true }
+	case "lts", "lts/*":
+		match = func(e IndexEntry) bool { return e.LTS != "" }
+	default:
+		if r, err := semver.ParseRange(spec); err == nil {
+			match = func(e IndexEntry) bool { return r.ContainsString(e.Version) }
+		} else {
+			codename := strings.TrimPrefix(spec, "lts/")
+			match = func(e IndexEntry) bool { return strings.ToLower(e.LTS) == codename }
+		}
+	}
+
+	var newest *IndexEntry
+	for i, e := range idx {
+		if match(e) && (newest == nil || semver.Compare(e.Version, newest.Version) > 0) {
+			newest = &idx[i]
+		}
+	}
+
+	if newest == nil {
+		return IndexEntry{}, fmt.Errorf("%w: %s", ErrUnknownVersion, spec)
+	}
+
+	return *newest, nil
+}
+
+// MatchLocal returns every installed version that spec matches, newest first. Unlike ResolveLocal, a version
+// prefix or range matches all the installed versions in it, not only the newest. Other specs, like aliases and LTS
+// names, match the single version they resolve to. An empty spec matches nothing, rather than every version like
+// the empty range does.
+func MatchLocal(p paths.Paths, spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, fmt.Errorf("%w: empty version", ErrUnknownVersion)
+	}
+
+	idx, err := GetLocalIndex(p.Versions())
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := semver.ParseRange(strings.TrimSpace(spec))
+	if err != nil {
+		v, err := resolveLocal(p, idx, spec, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{v}, nil
+	}
+
+	var matches []string
+	for _, e := range idx {
+		if r.ContainsString(e.Version) {
+			matches = append(matches, e.Version)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotInstalled, spec)
+	}
+
+	return matches, nil
+}
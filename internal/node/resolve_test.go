@@ -0,0 +1,194 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aronhoyer/go-nvm/internal/paths"
+)
+
+// testPaths returns an NVMDIR with a few installed versions, the lts files of the Iron (v20) and Hydrogen (v18)
+// lines, Iron being the latest, and some aliases.
+func testPaths(t *testing.T) paths.Paths {
+	t.Helper()
+
+	p := paths.Paths{Root: t.TempDir()}
+
+	for _, v := range []string{"v18.1.0", "v18.20.2", "v20.1.0", "v20.12.2", "v22.1.0"} {
+		if err := os.MkdirAll(p.Version(v), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a staging directory isn't an installed version
+	if err := os.MkdirAll(filepath.Join(p.Versions(), ".v23.0.0-123"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(p.LTS(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, v := range map[string]string{"iron": "v20.13.0", "hydrogen": "v18.20.2", "gallium": "v16.20.2"} {
+		if err := os.WriteFile(filepath.Join(p.LTS(), name), []byte(v), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink("iron", filepath.Join(p.LTS(), "latest")); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, target := range map[string]string{"default": "20.1", "old": "lts/hydrogen", "work": "old", "a": "b", "b": "a"} {
+		if err := WriteAlias(p.Aliases(), name, target); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return p
+}
+
+func TestResolveLocal(t *testing.T) {
+	p := testPaths(t)
+
+	tests := []struct {
+		spec, want string
+	}{
+		{"v20.1.0", "v20.1.0"},
+		{"20.1.0", "v20.1.0"},
+		{"20", "v20.12.2"},
+		{"v18", "v18.20.2"},
+		{"18.1", "v18.1.0"},
+		{"18.x", "v18.20.2"},
+		{">=18 <20", "v18.20.2"},
+		{"^20.1", "v20.12.2"},
+		{"latest", "v22.1.0"},
+		{"node", "v22.1.0"},
+		{" Latest ", "v22.1.0"},
+		{"system", SystemVersion},
+		{"lts", "v20.12.2"},
+		{"lts/*", "v20.12.2"},
+		{"lts/iron", "v20.12.2"},
+		{"lts/Hydrogen", "v18.20.2"},
+		{"iron", "v20.12.2"},
+		{"default", "v20.1.0"},
+		{"work", "v18.20.2"},
+		{"WORK", "v18.20.2"},
+	}
+
+	for _, tt := range tests {
+		if got, err := ResolveLocal(p, tt.spec); err != nil || got != tt.want {
+			t.Errorf("ResolveLocal(%q) = %q, %v, want %s", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveLocalErrors(t *testing.T) {
+	p := testPaths(t)
+
+	tests := []struct {
+		spec string
+		want error
+	}{
+		{"", ErrUnknownVersion},
+		{"  ", ErrUnknownVersion},
+		{"19", ErrNotInstalled},
+		{"v20.1.1", ErrNotInstalled},
+		{"lts/gallium", ErrNotInstalled},
+		{"lts/argon", ErrUnknownVersion},
+		{"argon", ErrUnknownVersion},
+		{"a", ErrUnknownVersion},
+	}
+
+	for _, tt := range tests {
+		if got, err := ResolveLocal(p, tt.spec); !errors.Is(err, tt.want) {
+			t.Errorf("ResolveLocal(%q) = %q, %v, want %v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestMatchLocal(t *testing.T) {
+	p := testPaths(t)
+
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"v20.1.0", []string{"v20.1.0"}},
+		{"20", []string{"v20.12.2", "v20.1.0"}},
+		{"18", []string{"v18.20.2", "v18.1.0"}},
+		{"v18.20", []string{"v18.20.2"}},
+		{">=18 <21", []string{"v20.12.2", "v20.1.0", "v18.20.2", "v18.1.0"}},
+		{"18 || 22", []string{"v22.1.0", "v18.20.2", "v18.1.0"}},
+		{"*", []string{"v22.1.0", "v20.12.2", "v20.1.0", "v18.20.2", "v18.1.0"}},
+		// aliases and LTS names only match the version they resolve to, not their whole line
+		{"default", []string{"v20.1.0"}},
+		{"work", []string{"v18.20.2"}},
+		{"lts/*", []string{"v20.12.2"}},
+		{"lts/hydrogen", []string{"v18.20.2"}},
+		{"iron", []string{"v20.12.2"}},
+		{"latest", []string{"v22.1.0"}},
+	}
+
+	for _, tt := range tests {
+		if got, err := MatchLocal(p, tt.spec); err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("MatchLocal(%q) = %v, %v, want %v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestMatchLocalErrors(t *testing.T) {
+	p := testPaths(t)
+
+	tests := []struct {
+		spec string
+		want error
+	}{
+		// the empty range matches everything, which an empty spec mustn't
+		{"", ErrUnknownVersion},
+		{" \t", ErrUnknownVersion},
+		{"19", ErrNotInstalled},
+		{">=23", ErrNotInstalled},
+		{"lts/argon", ErrUnknownVersion},
+		{"unknown", ErrUnknownVersion},
+	}
+
+	for _, tt := range tests {
+		if got, err := MatchLocal(p, tt.spec); !errors.Is(err, tt.want) {
+			t.Errorf("MatchLocal(%q) = %v, %v, want %v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveRemote(t *testing.T) {
+	tests := []struct {
+		spec, want string
+	}{
+		{"", "v22.1.0"},
+		{"latest", "v22.1.0"},
+		{"node", "v22.1.0"},
+		{"22.0", "v22.0.0"},
+		{"v20", "v20.12.2"},
+		{"20.12.1", "v20.12.1"},
+		{"<20", "v19.9.0"},
+		{"lts", "v20.12.2"},
+		{"lts/*", "v20.12.2"},
+		{"lts/hydrogen", "v18.20.2"},
+		{"Hydrogen", "v18.20.2"},
+	}
+
+	for _, tt := range tests {
+		if got, err := ResolveRemote(testIndex, tt.spec); err != nil || got.Version != tt.want {
+			t.Errorf("ResolveRemote(%q) = %s, %v, want %s", tt.spec, got.Version, err, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"21", "v20.12.3", "lts/argon", "argon"} {
+		if got, err := ResolveRemote(testIndex, spec); !errors.Is(err, ErrUnknownVersion) {
+			t.Errorf("ResolveRemote(%q) = %s, %v, want %v", spec, got.Version, err, ErrUnknownVersion)
+		}
+	}
+}
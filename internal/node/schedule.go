@@ -0,0 +1,143 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aronhoyer/go-nvm/internal/semver"
+)
+
+// Phase is the stage of the release lifecycle a Node release line is in.
+type Phase int
+
+const (
+	PhaseUnknown Phase = iota
+	// PhaseCurrent lines get new features, but aren't (yet) LTS.
+	PhaseCurrent
+	// PhaseActiveLTS lines get bug fixes and security fixes.
+	PhaseActiveLTS
+	// PhaseMaintenance lines only get critical bug fixes and security fixes.
+	PhaseMaintenance
+	// PhaseEOL lines get no updates at all.
+	PhaseEOL
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseCurrent:
+		return "current"
+	case PhaseActiveLTS:
+		return "active lts"
+	case PhaseMaintenance:
+		return "maintenance"
+	case PhaseEOL:
+		return "end-of-life"
+	default:
+		return "unknown"
+	}
+}
+
+// ReleaseLine is an entry in the Node release schedule. LTS and Maintenance are zero for lines that never became
+// LTS.
+type ReleaseLine struct {
+	Name                         string
+	Codename                     string
+	Start, LTS, Maintenance, End time.Time
+}
+
+// Phase returns the phase the line is in at t.
+func (l ReleaseLine) Phase(t time.Time) Phase {
+	switch {
+	case !l.End.IsZero() && !t.Before(l.End):
+		return PhaseEOL
+	case !l.Maintenance.IsZero() && !t.Before(l.Maintenance):
+		return PhaseMaintenance
+	case !l.LTS.IsZero() && !t.Before(l.LTS):
+		return PhaseActiveLTS
+	case !t.Before(l.Start):
+		return PhaseCurrent
+	default:
+		return PhaseUnknown
+	}
+}
+
+// Schedule is the Node release schedule, keyed by release line, e.g. "v20" or "v0.12".
+type Schedule map[string]ReleaseLine
+
+// Line returns the release line version belongs to.
+func (s Schedule) Line(version string) (ReleaseLine, bool) {
+	v, err := semver.Parse(version)
+	if err != nil {
+		return ReleaseLine{}, false
+	}
+
+	// before v1, every minor was its own line
+	name := fmt.Sprintf("v%d", v.Major)
+	if v.Major == 0 {
+		name = fmt.Sprintf("v0.%d", v.Minor)
+	}
+
+	l, ok := s[name]
+	return l, ok
+}
+
+// Phase returns the phase the release line of version is in at t.
+func (s Schedule) Phase(version string, t time.Time) Phase {
+	l, ok := s.Line(version)
+	if !ok {
+		return PhaseUnknown
+	}
+
+	return l.Phase(t)
+}
+
+// GetSchedule fetches the Node release schedule from ScheduleURL, or the cache.
+func GetSchedule() (Schedule, error) {
+	b, err := fetchCached(ScheduleURL, "schedule.json", ScheduleTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSchedule(b)
+}
+
+func parseSchedule(b []byte) (Schedule, error) {
+	var raw map[string]struct {
+		Start       string `json:"start"`
+		LTS         string `json:"lts"`
+		Maintenance string `json:"maintenance"`
+		End         string `json:"end"`
+		Codename    string `json:"codename"`
+	}
+
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("malformed release schedule: %w", err)
+	}
+
+	s := make(Schedule, len(raw))
+
+	for name, r := range raw {
+		l := ReleaseLine{Name: name, Codename: r.Codename}
+
+		for _, d := range []struct {
+			dst *time.Time
+			src string
+		}{{&l.Start, r.Start}, {&l.LTS, r.LTS}, {&l.Maintenance, r.Maintenance}, {&l.End, r.End}} {
+			if d.src == "" {
+				continue
+			}
+
+			t, err := time.Parse(time.DateOnly, d.src)
+			if err != nil {
+				return nil, fmt.Errorf("malformed release schedule: %s: %w", name, err)
+			}
+
+			*d.dst = t
+		}
+
+		s[name] = l
+	}
+
+	return s, nil
+}
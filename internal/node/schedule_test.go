@@ -0,0 +1,142 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testSchedule reads testdata/schedule.json through ScheduleURL, the way a schedule on a mirror would be read.
+func testSchedule(t *testing.T) Schedule {
+	t.Helper()
+
+	p, err := filepath.Abs(filepath.Join("testdata", "schedule.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, cacheDir := ScheduleURL, CacheDir
+	t.Cleanup(func() { ScheduleURL, CacheDir = url, cacheDir })
+
+	ScheduleURL = "file://" + filepath.ToSlash(p)
+	CacheDir = t.TempDir()
+
+	s, err := GetSchedule()
+	if err != nil {
+		t.Fatalf("GetSchedule: %s", err)
+	}
+
+	return s
+}
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func TestParseSchedule(t *testing.T) {
+	s := testSchedule(t)
+
+	if len(s) != 5 {
+		t.Errorf("got %d lines, want 5", len(s))
+	}
+
+	want := ReleaseLine{
+		Name:        "v18",
+		Codename:    "Hydrogen",
+		Start:       date("2022-04-19"),
+		LTS:         date("2022-10-25"),
+		Maintenance: date("2023-10-18"),
+		End:         date("2025-04-30"),
+	}
+
+	if got := s["v18"]; got != want {
+		t.Errorf("v18 = %+v, want %+v", got, want)
+	}
+
+	// lines that never became LTS have no LTS date
+	if got := s["v19"]; !got.LTS.IsZero() || got.Codename != "" {
+		t.Errorf("v19 = %+v, want no LTS", got)
+	}
+
+	if entries, _ := os.ReadDir(CacheDir); len(entries) != 0 {
+		t.Errorf("local schedule was cached")
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, in := range []string{
+		`not json`,
+		`{"v20": {"start": "20 April 2023"}}`,
+		`{"v20": {"start": "2023-04-18", "end": "2026-13-01"}}`,
+	} {
+		if s, err := parseSchedule([]byte(in)); err == nil {
+			t.Errorf("parseSchedule(%s) = %v, want an error", in, s)
+		}
+	}
+}
+
+func TestScheduleLine(t *testing.T) {
+	s := testSchedule(t)
+
+	tests := []struct {
+		version, line string
+	}{
+		{"v18.20.4", "v18"},
+		{"18.0.0", "v18"},
+		{"v0.10.48", "v0.10"},
+		{"v0.12.0", "v0.12"},
+		{"v0.11.16", ""},
+		{"v20.0.0", ""},
+		{"not a version", ""},
+	}
+
+	for _, tt := range tests {
+		l, ok := s.Line(tt.version)
+		if ok != (tt.line != "") || l.Name != tt.line {
+			t.Errorf("Line(%s) = %q, %t, want %q", tt.version, l.Name, ok, tt.line)
+		}
+	}
+}
+
+func TestReleaseLinePhase(t *testing.T) {
+	s := testSchedule(t)
+
+	tests := []struct {
+		line, at string
+		want     Phase
+	}{
+		{"v18", "2022-04-18", PhaseUnknown},
+		{"v18", "2022-04-19", PhaseCurrent},
+		{"v18", "2022-10-24", PhaseCurrent},
+		{"v18", "2022-10-25", PhaseActiveLTS},
+		{"v18", "2023-10-17", PhaseActiveLTS},
+		{"v18", "2023-10-18", PhaseMaintenance},
+		{"v18", "2025-04-29", PhaseMaintenance},
+		{"v18", "2025-04-30", PhaseEOL},
+		{"v19", "2023-03-31", PhaseCurrent},
+		{"v19", "2023-04-01", PhaseMaintenance},
+		{"v19", "2023-06-01", PhaseEOL},
+		{"v0.10", "2016-10-30", PhaseCurrent},
+		{"v0.10", "2016-10-31", PhaseEOL},
+	}
+
+	for _, tt := range tests {
+		if got := s[tt.line].Phase(date(tt.at)); got != tt.want {
+			t.Errorf("%s.Phase(%s) = %s, want %s", tt.line, tt.at, got, tt.want)
+		}
+	}
+
+	if got := s.Phase("v0.10.48", date("2020-01-01")); got != PhaseEOL {
+		t.Errorf("Phase(v0.10.48) = %s, want %s", got, PhaseEOL)
+	}
+
+	if got := s.Phase("v99.0.0", date("2020-01-01")); got != PhaseUnknown {
+		t.Errorf("Phase(v99.0.0) = %s, want %s", got, PhaseUnknown)
+	}
+}
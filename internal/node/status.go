@@ -0,0 +1,103 @@
+package node
+
+import (
+	"slices"
+	"time"
+
+	"github.com/aronhoyer/go-nvm/internal/semver"
+)
+
+// ReleaseStatus describes how an installed version compares to the remote releases in its major line.
+type ReleaseStatus struct {
+	Version string `json:"version"`
+	LTS     string `json:"lts,omitempty"`
+	// Latest is the newest release in the major line of Version.
+	Latest   string `json:"latest"`
+	Outdated bool   `json:"outdated"`
+	// Security is the newest security release in the major line that is newer than Version, if there is one.
+	Security string `json:"security,omitempty"`
+	// EOL is true if the major line is past end-of-life, and nil if that's unknown. It isn't set by
+	// GetReleaseStatus, since that requires the release schedule, see SetEOL.
+	EOL *bool `json:"eol"`
+}
+
+// SetEOL sets s.EOL from schedule, the release schedule, at the time at. It's left nil if the schedule has no
+// entry for the major line of s.Version.
+func (s *ReleaseStatus) SetEOL(schedule Schedule, at time.Time) {
+	s.EOL = nil
+	if phase := schedule.Phase(s.Version, at); phase != PhaseUnknown {
+		eol := phase == PhaseEOL
+		s.EOL = &eol
+	}
+}
+
+// GetReleaseStatus compares version to the releases in remote, the remote index.
+func GetReleaseStatus(version string, remote []IndexEntry) ReleaseStatus {
+	status := ReleaseStatus{Version: version, Latest: version}
+
+	v, err := semver.Parse(version)
+	if err != nil {
+		return status
+	}
+
+	for _, e := range remote {
+		rv, err := semver.Parse(e.Version)
+		if err != nil || rv.Major != v.Major {
+			continue
+		}
+
+		if e.Version == version {
+			status.LTS = e.LTS
+		}
+
+		if rv.Compare(v) <= 0 {
+			continue
+		}
+
+		if semver.Compare(e.Version, status.Latest) > 0 {
+			status.Latest = e.Version
+		}
+
+		if e.Security && (status.Security == "" || semver.Compare(e.Version, status.Security) > 0) {
+			status.Security = e.Version
+		}
+	}
+
+	status.Outdated = status.Latest != version
+	return status
+}
+
+// SecurityUpdates returns the status of the newest installed version of each major line in local, for the lines
+// that have a security release newer than it in remote, the remote index.
+func SecurityUpdates(local, remote []IndexEntry) []ReleaseStatus {
+	newest := make(map[int]string)
+	var majors []int
+
+	for _, e := range local {
+		v, err := semver.Parse(e.Version)
+		if err != nil {
+			continue
+		}
+
+		cur, ok := newest[v.Major]
+		if !ok {
+			majors = append(majors, v.Major)
+		}
+
+		if !ok || semver.Compare(e.Version, cur) > 0 {
+			newest[v.Major] = e.Version
+		}
+	}
+
+	slices.Sort(majors)
+	slices.Reverse(majors)
+
+	var updates []ReleaseStatus
+	for _, major := range majors {
+		if status := GetReleaseStatus(newest[major], remote); status.Security != "" {
+			updates = append(updates, status)
+		}
+	}
+
+	return updates
+}
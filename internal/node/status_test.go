@@ -0,0 +1,101 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetReleaseStatus(t *testing.T) {
+	tests := []struct {
+		version string
+		want    ReleaseStatus
+	}{
+		{"v22.1.0", ReleaseStatus{Version: "v22.1.0", Latest: "v22.1.0"}},
+		{"v22.0.0", ReleaseStatus{Version: "v22.0.0", Latest: "v22.1.0", Outdated: true}},
+		{"v20.12.2", ReleaseStatus{Version: "v20.12.2", LTS: "Iron", Latest: "v20.12.2"}},
+		{"v20.12.1", ReleaseStatus{Version: "v20.12.1", LTS: "Iron", Latest: "v20.12.2", Outdated: true, Security: "v20.12.2"}},
+		// a version no longer in the index, e.g. a nightly, still gets the newest release of its line
+		{"v18.0.0", ReleaseStatus{Version: "v18.0.0", Latest: "v18.20.2", Outdated: true, Security: "v18.20.2"}},
+		{"v16.0.0", ReleaseStatus{Version: "v16.0.0", Latest: "v16.0.0"}},
+		{"not a version", ReleaseStatus{Version: "not a version", Latest: "not a version"}},
+	}
+
+	for _, tt := range tests {
+		if got := GetReleaseStatus(tt.version, testIndex); got != tt.want {
+			t.Errorf("GetReleaseStatus(%s) = %+v, want %+v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestReleaseStatusSetEOL(t *testing.T) {
+	schedule := Schedule{
+		"v18": {Name: "v18", Start: date("2022-04-19"), End: date("2025-04-30")},
+		"v20": {Name: "v20", Start: date("2023-04-18"), End: date("2026-04-30")},
+	}
+	at := date("2025-06-01")
+
+	tests := []struct {
+		version string
+		// want is "yes", "no" or "" for unknown
+		want string
+	}{
+		{"v18.20.2", "yes"},
+		{"v20.12.2", "no"},
+		{"v22.1.0", ""},
+		{"v0.12.18", ""},
+	}
+
+	for _, tt := range tests {
+		s := GetReleaseStatus(tt.version, testIndex)
+		s.SetEOL(schedule, at)
+
+		got := ""
+		if s.EOL != nil {
+			got = map[bool]string{true: "yes", false: "no"}[*s.EOL]
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: EOL = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+
+	// without a schedule nothing is known
+	s := GetReleaseStatus("v18.20.2", testIndex)
+	s.SetEOL(nil, time.Now())
+	if s.EOL != nil {
+		t.Errorf("EOL = %t without a schedule, want nil", *s.EOL)
+	}
+}
+
+func TestSecurityUpdates(t *testing.T) {
+	local := []IndexEntry{
+		{Version: "v22.0.0"},
+		{Version: "v20.12.2"},
+		{Version: "v18.1.0"},
+		{Version: "v18.20.1"},
+		{Version: "v16.0.0"},
+	}
+
+	updates := SecurityUpdates(local, testIndex)
+
+	// v22.0.0 is outdated, but not by a security release, and v20.12.2 is the security release itself. Only the
+	// newest v18 counts, and v16 is no longer in the index
+	if len(updates) != 1 {
+		t.Fatalf("SecurityUpdates() = %+v, want only v18", updates)
+	}
+
+	want := ReleaseStatus{Version: "v18.20.1", LTS: "Hydrogen", Latest: "v18.20.2", Outdated: true, Security: "v18.20.2"}
+	if updates[0] != want {
+		t.Errorf("SecurityUpdates() = %+v, want %+v", updates[0], want)
+	}
+
+	if updates := SecurityUpdates(nil, testIndex); len(updates) != 0 {
+		t.Errorf("SecurityUpdates(nil) = %+v, want none", updates)
+	}
+
+	local = []IndexEntry{{Version: "v18.20.1"}, {Version: "v20.12.1"}, {Version: "v22.0.0"}}
+	updates = SecurityUpdates(local, testIndex)
+	if len(updates) != 2 || updates[0].Version != "v20.12.1" || updates[1].Version != "v18.20.1" {
+		t.Errorf("SecurityUpdates() = %+v, want v20.12.1 and v18.20.1, newest line first", updates)
+	}
+}
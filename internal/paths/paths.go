@@ -0,0 +1,160 @@
+package paths
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/aronhoyer/go-nvm/internal/platform"
+)
+
+var ErrNoHome = errors.New("unable to determine home directory")
+
+// configFileName is the name of the config file, in NVMDIR or in the go-nvm directory of XDG_CONFIG_HOME.
+const configFileName = "config.toml"
+
+// Paths are the locations of the files nvm manages.
+type Paths struct {
+	// Root holds the installed versions, the bin link, aliases and the lts files.
+	Root string
+	// Cache holds the remote index, the release schedule and downloads. They're fetched again if it's removed.
+	Cache string
+	// State holds history that's kept between runs, like the version files that were used.
+	State string
+	// ConfigFile is the config file. It doesn't necessarily exist.
+	ConfigFile string
+	// Legacy is the directory that was used before the XDG base directories were supported, ~/.nvm, whose
+	// contents belong in these paths. It's empty for an NVMDIR layout.
+	Legacy string
+}
+
+// Resolve returns the paths in NVMDIR if it's set, and the paths in the XDG base directories otherwise.
+func Resolve() (Paths, error) {
+	if dir := os.Getenv("NVMDIR"); dir != "" {
+		// the bin link points into the root, so a relative root would break it when used from elsewhere
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return Paths{}, err
+		}
+
+		return FromNVMDir(dir), nil
+	}
+
+	return FromXDG()
+}
+
+// FromNVMDir returns the paths of a single directory layout, where everything is kept in dir. The config file is
+// dir/config.toml, or $XDG_CONFIG_HOME/go-nvm/config.toml if only that one exists.
+func FromNVMDir(dir string) Paths {
+	p := Paths{
+		Root:       dir,
+		Cache:      path.Join(dir, "cache"),
+		State:      dir,
+		ConfigFile: path.Join(dir, configFileName),
+	}
+
+	if !fileExists(p.ConfigFile) {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(xdg) {
+			if xp := path.Join(xdg, "go-nvm", configFileName); fileExists(xp) {
+				p.ConfigFile = xp
+			}
+		}
+	}
+
+	return p
+}
+
+// FromXDG returns the paths in the go-nvm directories of the XDG base directories.
+func FromXDG() (Paths, error) {
+	var p Paths
+	var configDir string
+
+	for _, dir := range []struct {
+		dst  *string
+		base func() (string, error)
+	}{
+		{&p.Root, platform.DataHome},
+		{&p.Cache, platform.CacheHome},
+		{&configDir, platform.ConfigHome},
+		{&p.State, platform.StateHome},
+	} {
+		base, err := dir.base()
+		if err != nil {
+			return Paths{}, errors.Join(ErrNoHome, err)
+		}
+
+		*dir.dst = path.Join(base, "go-nvm")
+	}
+
+	p.ConfigFile = path.Join(configDir, configFileName)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Paths{}, errors.Join(ErrNoHome, err)
+	}
+	p.Legacy = path.Join(home, ".nvm")
+
+	return p, nil
+}
+
+// Init creates the directories that must exist for nvm to work.
+func (p Paths) Init() error {
+	for _, dir := range []string{p.Versions(), p.State} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Versions is the directory the Node versions are installed in, one directory per version.
+func (p Paths) Versions() string {
+	return path.Join(p.Root, "versions")
+}
+
+// Version is the installation directory of version, e.g. "v20.11.1".
+func (p Paths) Version(version string) string {
+	return path.Join(p.Versions(), version)
+}
+
+// Bin is the link to the bin directory of the active version, which is put in PATH.
+func (p Paths) Bin() string {
+	return path.Join(p.Root, "bin")
+}
+
+// Aliases is the directory holding an alias file per alias.
+func (p Paths) Aliases() string {
+	return path.Join(p.Root, "alias")
+}
+
+// LTS is the directory holding the newest version of each LTS line, per codename.
+func (p Paths) LTS() string {
+	return path.Join(p.Root, "lts")
+}
+
+// DefaultPackages lists the npm packages installed with every version.
+func (p Paths) DefaultPackages() string {
+	return path.Join(p.Root, "default-packages")
+}
+
+// Lock is the lock file that serializes the commands changing Root.
+func (p Paths) Lock() string {
+	return path.Join(p.Root, "nvm.lock")
+}
+
+// VersionFileHistory records when version files were last used.
+func (p Paths) VersionFileHistory() string {
+	return path.Join(p.State, "version-files")
+}
+
+// SecurityCheck is the timestamp of the last check for security releases.
+func (p Paths) SecurityCheck() string {
+	return path.Join(p.State, "security-check")
+}
+
+func fileExists(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,212 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// clearEnv unsets the variables the paths depend on for the duration of the test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	for _, k := range []string{"NVMDIR", "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_CONFIG_HOME", "XDG_STATE_HOME"} {
+		t.Setenv(k, "")
+	}
+}
+
+func TestResolveNVMDir(t *testing.T) {
+	clearEnv(t)
+
+	dir := t.TempDir()
+	t.Setenv("NVMDIR", dir)
+
+	p, err := Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Paths{
+		Root:       dir,
+		Cache:      filepath.Join(dir, "cache"),
+		State:      dir,
+		ConfigFile: filepath.Join(dir, "config.toml"),
+	}
+
+	if p != want {
+		t.Errorf("Resolve() = %+v, want %+v", p, want)
+	}
+}
+
+func TestResolveRelativeNVMDir(t *testing.T) {
+	clearEnv(t)
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("NVMDIR", "nvm")
+
+	p, err := Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the temporary directory may be behind a link, e.g. on macOS
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(wd, "nvm"); p.Root != want {
+		t.Errorf("Root = %s, want %s", p.Root, want)
+	}
+}
+
+func TestFromXDG(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Paths
+	}{
+		{
+			name: "unset",
+			env:  map[string]string{},
+			want: Paths{
+				Root:       filepath.Join(home, ".local", "share", "go-nvm"),
+				Cache:      filepath.Join(home, ".cache", "go-nvm"),
+				State:      filepath.Join(home, ".local", "state", "go-nvm"),
+				ConfigFile: filepath.Join(home, ".config", "go-nvm", "config.toml"),
+				Legacy:     filepath.Join(home, ".nvm"),
+			},
+		},
+		{
+			name: "set",
+			env: map[string]string{
+				"XDG_DATA_HOME":   filepath.Join(xdg, "data"),
+				"XDG_CACHE_HOME":  filepath.Join(xdg, "cache"),
+				"XDG_CONFIG_HOME": filepath.Join(xdg, "config"),
+				"XDG_STATE_HOME":  filepath.Join(xdg, "state"),
+			},
+			want: Paths{
+				Root:       filepath.Join(xdg, "data", "go-nvm"),
+				Cache:      filepath.Join(xdg, "cache", "go-nvm"),
+				State:      filepath.Join(xdg, "state", "go-nvm"),
+				ConfigFile: filepath.Join(xdg, "config", "go-nvm", "config.toml"),
+				Legacy:     filepath.Join(home, ".nvm"),
+			},
+		},
+		{
+			name: "relative",
+			env: map[string]string{
+				"XDG_DATA_HOME":   "data",
+				"XDG_CACHE_HOME":  "cache",
+				"XDG_CONFIG_HOME": "config",
+				"XDG_STATE_HOME":  "state",
+			},
+			want: Paths{
+				Root:       filepath.Join(home, ".local", "share", "go-nvm"),
+				Cache:      filepath.Join(home, ".cache", "go-nvm"),
+				State:      filepath.Join(home, ".local", "state", "go-nvm"),
+				ConfigFile: filepath.Join(home, ".config", "go-nvm", "config.toml"),
+				Legacy:     filepath.Join(home, ".nvm"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("HOME", home)
+			t.Setenv("USERPROFILE", home)
+
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			p, err := FromXDG()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p != tt.want {
+				t.Errorf("FromXDG() = %+v, want %+v", p, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromNVMDirConfigFile(t *testing.T) {
+	clearEnv(t)
+
+	dir := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	xdgConfig := filepath.Join(xdg, "go-nvm", "config.toml")
+	nvmConfig := filepath.Join(dir, "config.toml")
+
+	// neither exists, so the config is created in NVMDIR
+	if got := FromNVMDir(dir).ConfigFile; got != nvmConfig {
+		t.Errorf("without config files: ConfigFile = %s, want %s", got, nvmConfig)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(xdgConfig), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(xdgConfig, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FromNVMDir(dir).ConfigFile; got != xdgConfig {
+		t.Errorf("with only the XDG config: ConfigFile = %s, want %s", got, xdgConfig)
+	}
+
+	if err := os.WriteFile(nvmConfig, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FromNVMDir(dir).ConfigFile; got != nvmConfig {
+		t.Errorf("with both configs: ConfigFile = %s, want %s", got, nvmConfig)
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	p := Paths{Root: "/data", Cache: "/cache", State: "/state", ConfigFile: "/config/config.toml"}
+
+	tests := []struct {
+		name, got, want string
+	}{
+		{"Versions", p.Versions(), "/data/versions"},
+		{"Version", p.Version("v20.11.1"), "/data/versions/v20.11.1"},
+		{"Bin", p.Bin(), "/data/bin"},
+		{"Aliases", p.Aliases(), "/data/alias"},
+		{"LTS", p.LTS(), "/data/lts"},
+		{"DefaultPackages", p.DefaultPackages(), "/data/default-packages"},
+		{"Lock", p.Lock(), "/data/nvm.lock"},
+		{"VersionFileHistory", p.VersionFileHistory(), "/state/version-files"},
+		{"SecurityCheck", p.SecurityCheck(), "/state/security-check"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s() = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+	p := Paths{Root: filepath.Join(dir, "data"), State: filepath.Join(dir, "state")}
+
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range []string{p.Versions(), p.State} {
+		if info, err := os.Stat(d); err != nil || !info.IsDir() {
+			t.Errorf("%s wasn't created", d)
+		}
+	}
+}
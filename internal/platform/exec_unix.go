@@ -0,0 +1,11 @@
+//go:build !windows
+
+package platform
+
+import "syscall"
+
+// Exec replaces the current process with the program at path, so its exit status and any signals it receives are
+// exactly those of the program.
+func Exec(path string, args []string, env []string) error {
+	return syscall.Exec(path, args, env)
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// Exec runs the program at path and exits the current process with its exit status once it terminates. Windows
+// has no equivalent of execve(2), so the program runs as a child process with Ctrl+C left for it to handle.
+func Exec(path string, args []string, env []string) error {
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	// the console delivers Ctrl+C to every attached process; let the child decide what it means
+	signal.Ignore(os.Interrupt)
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+	}
+
+	os.Exit(cmd.ProcessState.ExitCode())
+	return nil
+}
@@ -0,0 +1,123 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockedError is returned when a lock is held by another process.
+type LockedError struct {
+	Path string
+	// PID is the process holding the lock, or 0 if it couldn't be determined.
+	PID int
+}
+
+func (e *LockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("%s is locked by another process (pid %d)", e.Path, e.PID)
+	}
+
+	return fmt.Sprintf("%s is locked by another process", e.Path)
+}
+
+// Lock is an advisory, exclusive lock on a file. It's released when Unlock is called or the process exits,
+// whichever comes first.
+type Lock struct {
+	path string
+	f    *os.File
+}
+
+// TryLock acquires the lock file at path without waiting. If another process holds it, a *LockedError is returned.
+// The PID of the current process is written to the lock file, for other processes to report.
+func TryLock(path string) (*Lock, error) {
+	for {
+		f, err := openLockFile(path)
+		if err == nil {
+			if err = lockFile(f); err != nil {
+				f.Close()
+			}
+		}
+
+		if err != nil {
+			if errors.Is(err, errWouldBlock) {
+				return nil, &LockedError{path, readLockPID(path)}
+			}
+
+			return nil, err
+		}
+
+		// the previous holder may have removed the file between us opening and locking it, in which case we hold
+		// a lock on a file no one else can see
+		if sameFile(f, path) {
+			f.Truncate(0)
+			f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+			slog.Debug("acquired lock", "path", path)
+			return &Lock{path, f}, nil
+		}
+
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+// AcquireLock is like TryLock, but retries until timeout has passed before giving up.
+func AcquireLock(path string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		l, err := TryLock(path)
+
+		var locked *LockedError
+		if !errors.As(err, &locked) || time.Now().After(deadline) {
+			return l, err
+		}
+
+		slog.Debug("waiting for lock", "path", path, "pid", locked.PID)
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Unlock releases the lock and removes the lock file.
+func (l *Lock) Unlock() error {
+	// remove before unlocking, so a process waiting for the lock never locks a file that's about to disappear
+	slog.Debug("releasing lock", "path", l.path)
+	rmErr := os.Remove(l.path)
+	unlockErr := unlockFile(l.f)
+	closeErr := l.f.Close()
+
+	return errors.Join(rmErr, unlockErr, closeErr)
+}
+
+func readLockPID(path string) int {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0
+	}
+
+	return pid
+}
+
+func sameFile(f *os.File, path string) bool {
+	a, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	b, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(a, b)
+}
@@ -0,0 +1,85 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTryLockHeld(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "nvm.lock")
+
+	l, err := TryLock(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = TryLock(p)
+
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("second TryLock error = %v, want a *LockedError", err)
+	}
+
+	if locked.Path != p || locked.PID != os.Getpid() {
+		t.Errorf("LockedError = %+v, want path %s and pid %d", locked, p, os.Getpid())
+	}
+
+	if err := l.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lock file left after Unlock: %v", err)
+	}
+
+	l, err = TryLock(p)
+	if err != nil {
+		t.Fatalf("TryLock after Unlock: %s", err)
+	}
+	l.Unlock()
+}
+
+func TestAcquireLockTimeout(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "nvm.lock")
+
+	l, err := TryLock(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Unlock()
+
+	start := time.Now()
+	_, err = AcquireLock(p, 300*time.Millisecond)
+
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("AcquireLock error = %v, want a *LockedError", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("AcquireLock gave up after %s, want at least the timeout", elapsed)
+	}
+}
+
+func TestAcquireLockWaits(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "nvm.lock")
+
+	l, err := TryLock(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		l.Unlock()
+	}()
+
+	l2, err := AcquireLock(p, 5*time.Second)
+	if err != nil {
+		t.Fatalf("AcquireLock: %s", err)
+	}
+	l2.Unlock()
+}
@@ -0,0 +1,100 @@
+package platform
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+var ErrNotFound = errors.New("executable file not found in PATH")
+
+// PathExcluding returns the PATH environment variable without the entries that are, or are inside, any of the
+// excluded directories.
+func PathExcluding(exclude ...string) string {
+	var excluded []string
+	for _, dir := range exclude {
+		if dir == "" {
+			continue
+		}
+
+		if abs, err := filepath.Abs(dir); err == nil {
+			excluded = append(excluded, filepath.Clean(abs))
+		}
+	}
+
+	var kept []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+
+		keep := true
+		for _, ex := range excluded {
+			if abs == ex || strings.HasPrefix(abs, ex+string(filepath.Separator)) {
+				keep = false
+				break
+			}
+		}
+
+		if keep {
+			kept = append(kept, dir)
+		}
+	}
+
+	return strings.Join(kept, string(os.PathListSeparator))
+}
+
+// LookPathIn searches the directories in pathList, formatted like the PATH environment variable, for an executable
+// named file and returns its absolute path.
+func LookPathIn(file, pathList string) (string, error) {
+	if runtime.GOOS == "windows" && filepath.Ext(file) == "" {
+		file += ".exe"
+	}
+
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+
+		p := filepath.Join(dir, file)
+		info, err := os.Stat(p)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		if runtime.GOOS != "windows" && info.Mode()&0o111 == 0 {
+			continue
+		}
+
+		return filepath.Abs(p)
+	}
+
+	return "", ErrNotFound
+}
+
+// DirSize returns the combined size of the files in the directory tree at root. Symlinks aren't followed.
+func DirSize(root string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
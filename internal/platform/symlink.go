@@ -1,17 +1,50 @@
 package platform
 
-import "os"
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
 
+// SymlinkForce creates newname as a symbolic link to oldname, replacing whatever is at newname. An existing link
+// is swapped for the new one atomically, so there's no moment at which newname doesn't exist.
 func SymlinkForce(oldname, newname string) error {
-	if _, err := os.Lstat(newname); err == nil {
-		if err := os.Remove(newname); err != nil {
+	// a rename can replace a link or a file, but not a directory
+	if info, err := os.Lstat(newname); err == nil && info.IsDir() {
+		if err := os.RemoveAll(newname); err != nil {
 			return err
 		}
 	}
 
-	if err := os.Symlink(oldname, newname); err != nil {
+	tmp, err := tempSymlink(oldname, newname)
+	if err != nil {
 		return err
 	}
 
+	slog.Debug("linking", "link", newname, "target", oldname, "tmp", tmp)
+	if err := replaceLink(tmp, newname); err != nil {
+		os.Remove(tmp)
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
 	return nil
 }
+
+// tempSymlink creates a link to oldname with a unique name next to newname, so it can be renamed over newname
+// without crossing file systems.
+func tempSymlink(oldname, newname string) (string, error) {
+	dir, base := filepath.Split(newname)
+
+	for {
+		tmp := filepath.Join(dir, "."+base+".tmp-"+strconv.FormatUint(rand.Uint64(), 36))
+
+		err := os.Symlink(oldname, tmp)
+		if !errors.Is(err, fs.ErrExist) {
+			return tmp, err
+		}
+	}
+}
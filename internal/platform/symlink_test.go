@@ -0,0 +1,141 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSymlinkForceConcurrent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("links are replaced by removing and renaming on Windows, which isn't atomic")
+	}
+
+	dir := t.TempDir()
+	targets := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")}
+	for _, target := range targets {
+		if err := os.Mkdir(target, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	link := filepath.Join(dir, "bin")
+	if err := SymlinkForce(targets[0], link); err != nil {
+		t.Fatal(err)
+	}
+
+	var stop atomic.Bool
+	var readers, writers sync.WaitGroup
+
+	for range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+
+			for !stop.Load() {
+				target, err := os.Readlink(link)
+				if err != nil {
+					t.Errorf("readlink: %s", err)
+					return
+				}
+
+				if _, err := os.Stat(link); err != nil {
+					t.Errorf("stat: %s", err)
+					return
+				}
+
+				found := false
+				for _, want := range targets {
+					found = found || target == want
+				}
+
+				if !found {
+					t.Errorf("link points to %s", target)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := range 4 {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+
+			for j := range 200 {
+				if err := SymlinkForce(targets[(i+j)%len(targets)], link); err != nil {
+					t.Errorf("swap: %s", err)
+					return
+				}
+			}
+		}()
+	}
+
+	writers.Wait()
+	stop.Store(true)
+	readers.Wait()
+
+	// no temporary links are left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != len(targets)+1 {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+
+		t.Errorf("directory holds %v, want the targets and the link", names)
+	}
+}
+
+func TestSymlinkForceReplaces(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(p string) error
+	}{
+		{"nothing", func(p string) error { return nil }},
+		{"link", func(p string) error { return os.Symlink("elsewhere", p) }},
+		{"regular file", func(p string) error { return os.WriteFile(p, []byte("x"), 0o644) }},
+		{"directory", func(p string) error {
+			if err := os.Mkdir(p, 0o755); err != nil {
+				return err
+			}
+
+			return os.WriteFile(filepath.Join(p, "node"), []byte("x"), 0o755)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, "target")
+			if err := os.Mkdir(target, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			link := filepath.Join(dir, "bin")
+			if err := tt.create(link); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := SymlinkForce(target, link); err != nil {
+				t.Fatalf("SymlinkForce: %s", err)
+			}
+
+			got, err := os.Readlink(link)
+			if err != nil {
+				t.Fatalf("readlink: %s", err)
+			}
+
+			if got != target {
+				t.Errorf("link points to %s, want %s", got, target)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package platform
+
+import "os"
+
+// replaceLink renames the link at tmp to newname, atomically replacing any link or file already there.
+func replaceLink(tmp, newname string) error {
+	return os.Rename(tmp, newname)
+}
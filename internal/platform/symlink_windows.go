@@ -0,0 +1,24 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// replaceLink renames the link at tmp to newname. Windows can't rename over a directory link, so when a rename
+// fails, the existing link is removed first, leaving a short window in which newname doesn't exist.
+func replaceLink(tmp, newname string) error {
+	err := os.Rename(tmp, newname)
+	if err == nil {
+		return nil
+	}
+
+	if rmErr := os.Remove(newname); rmErr != nil && !errors.Is(rmErr, fs.ErrNotExist) {
+		return err
+	}
+
+	return os.Rename(tmp, newname)
+}
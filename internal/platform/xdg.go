@@ -0,0 +1,42 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DataHome returns the base directory for user data files, $XDG_DATA_HOME or ~/.local/share.
+func DataHome() (string, error) {
+	return xdgDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// CacheHome returns the base directory for non-essential cached data, $XDG_CACHE_HOME or ~/.cache.
+func CacheHome() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// ConfigHome returns the base directory for configuration files, $XDG_CONFIG_HOME or ~/.config.
+func ConfigHome() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// StateHome returns the base directory for state that should persist between runs but isn't important enough to
+// be kept with the data, e.g. history, $XDG_STATE_HOME or ~/.local/state.
+func StateHome() (string, error) {
+	return xdgDir("XDG_STATE_HOME", ".local", "state")
+}
+
+// xdgDir returns the directory in the environment variable env, or the default path under the home directory if
+// it's unset. Relative paths are invalid per the spec, and ignored like unset ones.
+func xdgDir(env string, def ...string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(append([]string{home}, def...)...), nil
+}
@@ -0,0 +1,193 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidRange = errors.New("invalid version range")
+
+type operator int
+
+const (
+	opEQ operator = iota
+	opLT
+	opLTE
+	opGT
+	opGTE
+)
+
+type comparator struct {
+	op operator
+	v  Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.v)
+
+	switch c.op {
+	case opLT:
+		return cmp < 0
+	case opLTE:
+		return cmp <= 0
+	case opGT:
+		return cmp > 0
+	case opGTE:
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// Range is a set of version constraints in the npm style, e.g. "20", "18.x", ">=18 <20", "^18.2" or "16 || 18".
+// Whitespace separated comparators must all match, "||" separated sets are alternatives.
+type Range struct {
+	sets [][]comparator
+}
+
+// ParseRange parses s into a Range. A bare (possibly partial) version is a range of every version it is a prefix
+// of, so "20" matches v20.0.0 through v20.x.x.
+func ParseRange(s string) (Range, error) {
+	var r Range
+
+	for _, alt := range strings.Split(s, "||") {
+		fields := strings.Fields(alt)
+
+		// allow whitespace between an operator and its version, e.g. ">= 18"
+		var tokens []string
+		for i := 0; i < len(fields); i++ {
+			if strings.Trim(fields[i], "<>=^~") == "" && i+1 < len(fields) {
+				tokens = append(tokens, fields[i]+fields[i+1])
+				i++
+				continue
+			}
+			tokens = append(tokens, fields[i])
+		}
+
+		if len(tokens) == 0 {
+			if strings.TrimSpace(s) != "" {
+				return Range{}, fmt.Errorf("%w: %s", ErrInvalidRange, s)
+			}
+
+			tokens = []string{"*"}
+		}
+
+		var set []comparator
+		for _, t := range tokens {
+			cs, err := parseComparator(t)
+			if err != nil {
+				return Range{}, fmt.Errorf("%w: %s", ErrInvalidRange, s)
+			}
+			set = append(set, cs...)
+		}
+
+		r.sets = append(r.sets, set)
+	}
+
+	return r, nil
+}
+
+// Contains reports whether v satisfies the range.
+func (r Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		ok := true
+		for _, c := range set {
+			if !c.matches(v) {
+				ok = false
+				break
+			}
+		}
+
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContainsString is like Contains but parses v first. Invalid versions are never contained.
+func (r Range) ContainsString(v string) bool {
+	sv, err := Parse(v)
+	if err != nil {
+		return false
+	}
+
+	return r.Contains(sv)
+}
+
+func parseComparator(t string) ([]comparator, error) {
+	if t == "*" || t == "x" || t == "X" {
+		return []comparator{{opGTE, Version{}}}, nil
+	}
+
+	var prefix string
+	for _, p := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(t, p) {
+			prefix = p
+			t = t[len(p):]
+			break
+		}
+	}
+
+	maj, min, patch, pre, n, err := parsePartial(t)
+	if err != nil {
+		return nil, err
+	}
+
+	lo := Version{maj, min, patch, pre}
+
+	// upper bound of a partial version, e.g. 18 -> 19.0.0 and 18.2 -> 18.3.0
+	hi := func() Version {
+		switch n {
+		case 1:
+			return Version{Major: maj + 1}
+		case 2:
+			return Version{Major: maj, Minor: min + 1}
+		default:
+			return Version{Major: maj, Minor: min, Patch: patch + 1}
+		}
+	}
+
+	switch prefix {
+	case ">=":
+		return []comparator{{opGTE, lo}}, nil
+	case ">":
+		if n < 3 {
+			return []comparator{{opGTE, hi()}}, nil
+		}
+		return []comparator{{opGT, lo}}, nil
+	case "<":
+		return []comparator{{opLT, lo}}, nil
+	case "<=":
+		if n < 3 {
+			return []comparator{{opLT, hi()}}, nil
+		}
+		return []comparator{{opLTE, lo}}, nil
+	case "^":
+		var upper Version
+		switch {
+		case maj > 0 || n == 1:
+			upper = Version{Major: maj + 1}
+		case min > 0 || n == 2:
+			upper = Version{Minor: min + 1}
+		default:
+			upper = Version{Patch: patch + 1}
+		}
+		return []comparator{{opGTE, lo}, {opLT, upper}}, nil
+	case "~":
+		if n == 1 {
+			return []comparator{{opGTE, lo}, {opLT, Version{Major: maj + 1}}}, nil
+		}
+		return []comparator{{opGTE, lo}, {opLT, Version{Major: maj, Minor: min + 1}}}, nil
+	default:
+		if n == 0 {
+			return []comparator{{opGTE, Version{}}}, nil
+		}
+		if n == 3 {
+			return []comparator{{opEQ, lo}}, nil
+		}
+		return []comparator{{opGTE, lo}, {opLT, hi()}}, nil
+	}
+}
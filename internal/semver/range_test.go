@@ -0,0 +1,88 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		rng     string
+		in, out []string
+	}{
+		{"", []string{"0.0.0", "v20.11.1"}, nil},
+		{"*", []string{"0.0.0", "v20.11.1"}, nil},
+		{"20", []string{"20.0.0", "20.99.99"}, []string{"19.9.9", "21.0.0"}},
+		{"v18.2", []string{"18.2.0", "18.2.9"}, []string{"18.1.9", "18.3.0"}},
+		{"18.x", []string{"18.0.0", "18.20.4"}, []string{"17.9.9", "19.0.0"}},
+		{"18.2.1", []string{"18.2.1"}, []string{"18.2.0", "18.2.2"}},
+		{"=18.2.1", []string{"18.2.1"}, []string{"18.2.2"}},
+
+		{"^18.2", []string{"18.2.0", "18.99.0"}, []string{"18.1.9", "19.0.0"}},
+		{"^18", []string{"18.0.0", "18.99.0"}, []string{"17.9.9", "19.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.2.2", "0.3.0"}},
+		{"^0.2", []string{"0.2.0", "0.2.9"}, []string{"0.1.9", "0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.2", "0.0.4"}},
+		{"^0.0", []string{"0.0.0", "0.0.9"}, []string{"0.1.0"}},
+		{"^0.x", []string{"0.0.0", "0.12.18"}, []string{"1.0.0"}},
+		{"^0", []string{"0.0.0", "0.12.18"}, []string{"1.0.0"}},
+
+		{"~18.2.1", []string{"18.2.1", "18.2.9"}, []string{"18.2.0", "18.3.0"}},
+		{"~18.2", []string{"18.2.0", "18.2.9"}, []string{"18.1.9", "18.3.0"}},
+		{"~18", []string{"18.0.0", "18.9.9"}, []string{"17.9.9", "19.0.0"}},
+
+		{">18", []string{"19.0.0"}, []string{"18.0.0", "18.99.99"}},
+		{">18.2", []string{"18.3.0"}, []string{"18.2.9"}},
+		{">18.2.1", []string{"18.2.2"}, []string{"18.2.1"}},
+		{">=18", []string{"18.0.0", "22.0.0"}, []string{"17.9.9"}},
+		{">= 18", []string{"18.0.0", "22.0.0"}, []string{"17.9.9"}},
+		{"<18", []string{"17.9.9"}, []string{"18.0.0", "18.0.1"}},
+		{"<=18", []string{"18.0.0", "18.99.99"}, []string{"19.0.0"}},
+		{"<=18.2", []string{"18.2.9"}, []string{"18.3.0"}},
+		{"<=18.2.1", []string{"18.2.1"}, []string{"18.2.2"}},
+
+		{">=18 <20", []string{"18.0.0", "19.9.9"}, []string{"17.9.9", "20.0.0"}},
+		{">= 18 < 20", []string{"18.0.0", "19.9.9"}, []string{"17.9.9", "20.0.0"}},
+		{"16 || 18", []string{"16.0.0", "18.1.0"}, []string{"17.0.0", "20.0.0"}},
+		{"<16 || >=20 <21", []string{"15.0.0", "20.5.0"}, []string{"16.0.0", "21.0.0"}},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %s", tt.rng, err)
+			continue
+		}
+
+		for _, v := range tt.in {
+			if !r.ContainsString(v) {
+				t.Errorf("%q doesn't contain %s", tt.rng, v)
+			}
+		}
+
+		for _, v := range tt.out {
+			if r.ContainsString(v) {
+				t.Errorf("%q contains %s", tt.rng, v)
+			}
+		}
+	}
+}
+
+func TestParseRangeErrors(t *testing.T) {
+	for _, in := range []string{"lts", "iron", ">=", ">=a", "^", "1.2.3.4", "18 ||", "|| 18"} {
+		if _, err := ParseRange(in); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("ParseRange(%q) error = %v, want %v", in, err, ErrInvalidRange)
+		}
+	}
+}
+
+func TestContainsStringInvalid(t *testing.T) {
+	r, err := ParseRange("*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.ContainsString("not a version") {
+		t.Error("* contains an invalid version")
+	}
+}
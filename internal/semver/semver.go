@@ -0,0 +1,153 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidVersion = errors.New("invalid version")
+
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          string
+}
+
+// Parse parses a complete version such as "v20.11.1" or "9.8.1". The leading "v" is optional.
+func Parse(s string) (Version, error) {
+	maj, min, patch, pre, n, err := parsePartial(s)
+	if err != nil {
+		return Version{}, err
+	}
+
+	if n != 3 {
+		return Version{}, fmt.Errorf("%w: %s", ErrInvalidVersion, s)
+	}
+
+	return Version{maj, min, patch, pre}, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+
+	return s
+}
+
+// Compare returns -1 if v is lower than o, 1 if v is higher than o, and 0 if they're equal. A prerelease version
+// is lower than the release it precedes.
+func (v Version) Compare(o Version) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	}
+
+	if c := compareInt(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+
+	if c := compareInt(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	default:
+		return strings.Compare(v.Prerelease, o.Prerelease)
+	}
+}
+
+// Compare compares two version strings, ordering unparseable versions before valid ones. It is meant to be used
+// with [slices.SortFunc].
+func Compare(a, b string) int {
+	av, aerr := Parse(a)
+	bv, berr := Parse(b)
+
+	switch {
+	case aerr != nil && berr != nil:
+		return strings.Compare(a, b)
+	case aerr != nil:
+		return -1
+	case berr != nil:
+		return 1
+	}
+
+	return av.Compare(bv)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parsePartial parses a possibly incomplete version, e.g. "18", "v18.2" or "18.x". n is the number of numeric
+// components that were specified; wildcards ("x", "X", "*") terminate the version.
+func parsePartial(s string) (maj, min, patch int, pre string, n int, err error) {
+	raw := s
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "="), "v")
+
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, pre = s[:i], s[i+1:]
+	}
+
+	if s == "" {
+		err = fmt.Errorf("%w: %s", ErrInvalidVersion, raw)
+		return
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		err = fmt.Errorf("%w: %s", ErrInvalidVersion, raw)
+		return
+	}
+
+	nums := [3]int{}
+	for i, p := range parts {
+		if isWildcard(p) {
+			for _, rest := range parts[i+1:] {
+				if !isWildcard(rest) {
+					err = fmt.Errorf("%w: %s", ErrInvalidVersion, raw)
+					return
+				}
+			}
+			break
+		}
+
+		num, convErr := strconv.Atoi(p)
+		if convErr != nil || num < 0 {
+			err = fmt.Errorf("%w: %s", ErrInvalidVersion, raw)
+			return
+		}
+
+		nums[i] = num
+		n++
+	}
+
+	if pre != "" && n != 3 {
+		err = fmt.Errorf("%w: %s", ErrInvalidVersion, raw)
+		return
+	}
+
+	return nums[0], nums[1], nums[2], pre, n, nil
+}
+
+func isWildcard(s string) bool {
+	return s == "x" || s == "X" || s == "*"
+}
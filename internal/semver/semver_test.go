@@ -0,0 +1,100 @@
+package semver
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParsePartial(t *testing.T) {
+	tests := []struct {
+		in              string
+		maj, min, patch int
+		pre             string
+		n               int
+	}{
+		{"20", 20, 0, 0, "", 1},
+		{"v20", 20, 0, 0, "", 1},
+		{"=v20", 20, 0, 0, "", 1},
+		{"18.2", 18, 2, 0, "", 2},
+		{"v18.2.1", 18, 2, 1, "", 3},
+		{"18.x", 18, 0, 0, "", 1},
+		{"18.X.x", 18, 0, 0, "", 1},
+		{"18.2.*", 18, 2, 0, "", 2},
+		{"x", 0, 0, 0, "", 0},
+		{"0.0.0", 0, 0, 0, "", 3},
+		{"v21.0.0-rc.1", 21, 0, 0, "rc.1", 3},
+		{"1.2.3+build.5", 1, 2, 3, "", 3},
+		{"1.2.3-beta+build", 1, 2, 3, "beta", 3},
+	}
+
+	for _, tt := range tests {
+		maj, min, patch, pre, n, err := parsePartial(tt.in)
+		if err != nil {
+			t.Errorf("parsePartial(%q): %s", tt.in, err)
+			continue
+		}
+
+		if maj != tt.maj || min != tt.min || patch != tt.patch || pre != tt.pre || n != tt.n {
+			t.Errorf("parsePartial(%q) = %d, %d, %d, %q, %d, want %d, %d, %d, %q, %d",
+				tt.in, maj, min, patch, pre, n, tt.maj, tt.min, tt.patch, tt.pre, tt.n)
+		}
+	}
+}
+
+func TestParsePartialErrors(t *testing.T) {
+	for _, in := range []string{"", "v", "-1", "1.2.3.4", "1.x.3", "a", "1.a", "1..2", "1.2-beta", "lts/iron"} {
+		if _, _, _, _, _, err := parsePartial(in); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("parsePartial(%q) error = %v, want %v", in, err, ErrInvalidVersion)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if v, err := Parse("v20.11.1"); err != nil || v != (Version{20, 11, 1, ""}) {
+		t.Errorf("Parse(v20.11.1) = %v, %v", v, err)
+	}
+
+	// partial versions aren't complete versions
+	for _, in := range []string{"20", "20.11", "20.x"} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("Parse(%q) error = %v, want %v", in, err, ErrInvalidVersion)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v20.0.0", "v20.0.0", 0},
+		{"20.0.0", "v20.0.0", 0},
+		{"v9.0.0", "v10.0.0", -1},
+		{"v20.10.0", "v20.9.0", 1},
+		{"v20.0.10", "v20.0.9", 1},
+		{"v21.0.0-rc.1", "v21.0.0", -1},
+		{"v21.0.0-rc.1", "v21.0.0-rc.2", -1},
+		{"v21.0.0-rc.1", "v20.99.99", 1},
+		{"invalid", "v0.0.1", -1},
+		{"v0.0.1", "invalid", 1},
+		{"a", "b", -1},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+
+		if got := Compare(tt.b, tt.a); got != -tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+
+	versions := []string{"v18.20.4", "v20.0.0", "v8.17.0", "v20.0.0-rc.1", "v0.12.18", "v18.3.0"}
+	slices.SortFunc(versions, Compare)
+	want := []string{"v0.12.18", "v8.17.0", "v18.3.0", "v18.20.4", "v20.0.0-rc.1", "v20.0.0"}
+	if !slices.Equal(versions, want) {
+		t.Errorf("sorted = %v, want %v", versions, want)
+	}
+}